@@ -0,0 +1,19 @@
+Feature: Output capture
+  As a user, I want failure notifications to include the end of the
+  command's output, so that I can see why it failed.
+
+  Scenario: Output is not captured by default
+    When I run "nf -t 0 -- exit 1"
+    Then the notification output should be ""
+
+  Scenario: Capturing stderr only
+    When I run "nf -t 0 --capture stderr -- exit 1"
+    Then the notification output should be "error: exiting with 1"
+
+  Scenario: Capturing combined output
+    When I run "nf -t 0 --capture combined -- exit 2"
+    Then the notification output should be "building...\nerror: exiting with 2"
+
+  Scenario: Unknown capture mode
+    When I run "nf --capture everything -- exit 1"
+    Then the command should fail with an error containing "unknown capture mode"
@@ -1,7 +1,6 @@
 package features
 
 import (
-	"context"
 	"testing"
 
 	"github.com/cucumber/godog"
@@ -0,0 +1,18 @@
+Feature: Output match triggers
+  As a user, I want to be notified as soon as a long-running command prints
+  something I am waiting for, without waiting for it to exit.
+
+  Scenario: Notifying when the output matches
+    When I run "nf -t 100 --on-match exiting -- exit 0"
+    Then I should receive a notification
+    And the notification title should contain "Output Matched: exit"
+    And the notification message should contain "matching `exiting`"
+    And the notification output should be "error: exiting with 0"
+
+  Scenario: No notification when nothing matches
+    When I run "nf -t 100 --on-match panic -- exit 0"
+    Then I should not receive a notification
+
+  Scenario: Invalid pattern
+    When I run "nf --on-match ( -- exit 0"
+    Then the command should fail with an error containing "invalid on_match pattern"
@@ -25,3 +25,54 @@ Feature: Command execution notification
   Scenario: No command provided
     When I run "nf --"
     Then the command should fail with an error containing "a command to execute is required"
+
+  Scenario: Successful command is reported as succeeded
+    Given the default threshold is 10 seconds
+    When I run "nf -- sleep 11"
+    Then the notification title should contain "Command Succeeded"
+    And nf should exit with code 0
+
+  Scenario: Failing command propagates its exit code
+    When I run "nf -t 0 -- exit 2"
+    Then the notification title should contain "Command Failed"
+    And the notification message should contain "failed with exit code 2"
+    And the notification should report exit code 2
+    And nf should exit with code 2
+
+  Scenario: Failing command propagates its exit code without a notification
+    Given the default threshold is 10 seconds
+    When I run "nf -- exit 3"
+    Then I should not receive a notification
+    And nf should exit with code 3
+
+  Scenario: Command killed by a signal
+    When I run "nf -t 0 -- kill 15"
+    Then the notification title should contain "Command Killed"
+    And the notification message should contain "was killed by SIGTERM"
+    And nf should exit with code 143
+
+  Scenario: Several notifiers can be selected with a repeated flag
+    When I run "nf --notifier os --notifier slack -- sleep 11"
+    Then I should receive a notification
+    And the configured notifiers should be "os,slack"
+
+  Scenario: Several notifiers can be selected with a comma-separated environment variable
+    Given the environment variable "NF_NOTIFIER" is set to "os,slack"
+    When I run "nf -- sleep 11"
+    Then the configured notifiers should be "os,slack"
+
+  Scenario: Command interrupted by a signal sent to nf
+    When I run "nf -t 0 -- interrupt 15"
+    Then the notification title should contain "Command Interrupted"
+    And the notification message should contain "was interrupted by SIGTERM"
+    And nf should exit with code 143
+
+  Scenario: Command that exceeds its timeout
+    When I run "nf --timeout 90s -- hang 8"
+    Then the notification title should contain "Command Timed Out"
+    And the notification message should contain "timed out after 1m30s"
+    And nf should exit with code 124
+
+  Scenario: Invalid timeout signal
+    When I run "nf --timeout 90s --timeout-signal BOGUS -- hang 8"
+    Then the command should fail with an error containing "unknown signal"
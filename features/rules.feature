@@ -0,0 +1,36 @@
+Feature: Rule-based notification routing
+  As a user, I want to route notifications to different notifiers
+  depending on the command and how it finished.
+
+  Background:
+    Given the config file contains:
+      """
+      notifier = "os"
+
+      [[rules]]
+      name = "terraform failures"
+      command = "terraform apply*"
+      outcome = "failed"
+      notifiers = ["teams"]
+
+      [[rules]]
+      name = "long jobs"
+      min_duration = "30m"
+      notifiers = ["app"]
+
+      [[rules]]
+      name = "quiet sleeps"
+      command_regex = "^sleep 1[0-9]$"
+      exit_codes = [0]
+      notifiers = []
+      stop = true
+      """
+
+  Scenario: Commands matching no rule use the default notifiers
+    When I run "nf -t 0 -- exit 1"
+    Then I should receive a notification
+    And the configured notifiers should be "os"
+
+  Scenario: A rule with no notifiers suppresses the default ones
+    When I run "nf -- sleep 11"
+    Then the configured notifiers should be ""
@@ -4,14 +4,17 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"io"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/cucumber/godog"
 	"github.com/jules-labs/nf/internal/cmd"
 	"github.com/jules-labs/nf/internal/notifier"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -20,21 +23,26 @@ type testState struct {
 	output         *bytes.Buffer
 	err            error
 	notification   *mockNotification
-	originalRunner func(args []string) (time.Duration, error)
+	config         cmd.Config
+	env            []string
+	configFile     string
+	originalRunner func(args []string, opts cmd.RunOptions) (time.Duration, cmd.ExitStatus, error)
 	originalGetter func(config cmd.Config) (notifier.Notifier, error)
 }
 
 // mockNotification is a mock notifier for testing.
 type mockNotification struct {
 	wasCalled bool
+	event     notifier.Event
 	title     string
 	message   string
 }
 
-func (m *mockNotification) Notify(title, message string) error {
+func (m *mockNotification) Send(ctx context.Context, event notifier.Event) error {
 	m.wasCalled = true
-	m.title = title
-	m.message = message
+	m.event = event
+	m.title = event.Title()
+	m.message = event.Message()
 	return nil
 }
 
@@ -44,6 +52,14 @@ func (s *testState) registerSteps(ctx *godog.ScenarioContext) {
 	ctx.Step(`^I should receive a notification$`, s.iShouldReceiveANotification)
 	ctx.Step(`^I should not receive a notification$`, s.iShouldNotReceiveANotification)
 	ctx.Step(`^the command should fail with an error containing "([^"]*)"$`, s.theCommandShouldFailWithAnErrorContaining)
+	ctx.Step(`^the notification title should contain "([^"]*)"$`, s.theNotificationTitleShouldContain)
+	ctx.Step(`^the notification message should contain "([^"]*)"$`, s.theNotificationMessageShouldContain)
+	ctx.Step(`^nf should exit with code (\d+)$`, s.nfShouldExitWithCode)
+	ctx.Step(`^the notification should report exit code (\d+)$`, s.theNotificationShouldReportExitCode)
+	ctx.Step(`^the environment variable "([^"]*)" is set to "([^"]*)"$`, s.theEnvironmentVariableIsSetTo)
+	ctx.Step(`^the configured notifiers should be "([^"]*)"$`, s.theConfiguredNotifiersShouldBe)
+	ctx.Step(`^the config file contains:$`, s.theConfigFileContains)
+	ctx.Step(`^the notification output should be "([^"]*)"$`, s.theNotificationOutputShouldBe)
 
 	ctx.Before(s.setup)
 	ctx.After(s.teardown)
@@ -57,22 +73,47 @@ func (s *testState) setup(ctx context.Context, sc *godog.Scenario) (context.Cont
 	// Replace the real GetNotifier with our mock version
 	s.originalGetter = cmd.GetNotifier
 	cmd.GetNotifier = func(config cmd.Config) (notifier.Notifier, error) {
+		s.config = config
 		return s.notification, nil
 	}
 
 	// Replace the real runCommand with our mock version
 	s.originalRunner = cmd.RunCommand
-	cmd.RunCommand = func(args []string) (time.Duration, error) {
-		// The mock command is expected to be in the format "sleep <seconds>"
-		if len(args) > 0 && args[0] == "sleep" && len(args) > 1 {
-			seconds, err := strconv.Atoi(args[1])
-			if err != nil {
-				return 0, fmt.Errorf("mock sleep: invalid duration %s", args[1])
-			}
+	cmd.RunCommand = func(args []string, opts cmd.RunOptions) (time.Duration, cmd.ExitStatus, error) {
+		// The mock commands are expected to be in one of the formats
+		// "sleep <seconds>", "exit <code>", "kill <signal number>",
+		// "interrupt <signal number>" or "hang <hours>".
+		if len(args) < 2 {
+			return 0, cmd.ExitStatus{}, nil
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil {
+			return 0, cmd.ExitStatus{}, fmt.Errorf("mock %s: invalid argument %s", args[0], args[1])
+		}
+		switch args[0] {
+		case "sleep":
 			// Simulate the execution time
-			return time.Duration(seconds) * time.Second, nil
+			return time.Duration(n) * time.Second, cmd.ExitStatus{}, nil
+		case "exit":
+			// Simulate some output so that output capture can be tested.
+			if opts.Stdout != nil {
+				fmt.Fprintln(opts.Stdout, "building...")
+			}
+			if opts.Stderr != nil {
+				fmt.Fprintf(opts.Stderr, "error: exiting with %d\n", n)
+			}
+			return 0, cmd.ExitStatus{Code: n}, nil
+		case "kill":
+			return 0, cmd.ExitStatus{Code: 128 + n, Signal: syscall.Signal(n)}, nil
+		case "hang":
+			if opts.Timeout > 0 {
+				return opts.Timeout, cmd.ExitStatus{Code: 124, Signal: opts.TimeoutSignal, TimedOut: true, Timeout: opts.Timeout}, nil
+			}
+			return time.Duration(n) * time.Hour, cmd.ExitStatus{}, nil
+		case "interrupt":
+			return 0, cmd.ExitStatus{Code: 128 + n, Signal: syscall.Signal(n), Interrupt: syscall.Signal(n)}, nil
 		}
-		return 0, nil
+		return 0, cmd.ExitStatus{}, nil
 	}
 
 	return ctx, nil
@@ -83,6 +124,16 @@ func (s *testState) teardown(ctx context.Context, sc *godog.Scenario, err error)
 	// Restore the original functions
 	cmd.GetNotifier = s.originalGetter
 	cmd.RunCommand = s.originalRunner
+	for _, name := range s.env {
+		os.Unsetenv(name)
+	}
+	s.env = nil
+	if s.configFile != "" {
+		os.RemoveAll(filepath.Dir(s.configFile))
+		s.configFile = ""
+	}
+	// Config values read from a file would otherwise leak into later scenarios.
+	viper.Reset()
 	return ctx, nil
 }
 
@@ -100,8 +151,11 @@ func (s *testState) iRun(command string) error {
 	rootCmd.SetOut(s.output)
 	rootCmd.SetErr(s.output)
 
-	args := strings.Split(command, " ")
-	rootCmd.SetArgs(args[1:]) // "nf" is the command name, so we skip it
+	args := strings.Split(command, " ")[1:] // "nf" is the command name, so we skip it
+	if s.configFile != "" {
+		args = append([]string{"--config", s.configFile}, args...)
+	}
+	rootCmd.SetArgs(args)
 
 	s.err = rootCmd.Execute()
 	return nil
@@ -126,6 +180,71 @@ func (s *testState) theCommandShouldFailWithAnErrorContaining(errorString string
 	return t.err
 }
 
+func (s *testState) theNotificationTitleShouldContain(text string) error {
+	t := &testingT{}
+	assert.True(t, s.notification.wasCalled, "Expected a notification to be sent, but it was not")
+	assert.Contains(t, s.notification.title, text, "Notification title does not contain expected string")
+	return t.err
+}
+
+func (s *testState) theNotificationMessageShouldContain(text string) error {
+	t := &testingT{}
+	assert.True(t, s.notification.wasCalled, "Expected a notification to be sent, but it was not")
+	assert.Contains(t, s.notification.message, text, "Notification message does not contain expected string")
+	return t.err
+}
+
+func (s *testState) theNotificationShouldReportExitCode(code int) error {
+	t := &testingT{}
+	assert.True(t, s.notification.wasCalled, "Expected a notification to be sent, but it was not")
+	assert.Equal(t, code, s.notification.event.ExitCode, "Unexpected exit code in notification event")
+	return t.err
+}
+
+func (s *testState) theEnvironmentVariableIsSetTo(name, value string) error {
+	s.env = append(s.env, name)
+	return os.Setenv(name, value)
+}
+
+func (s *testState) theConfiguredNotifiersShouldBe(names string) error {
+	t := &testingT{}
+	if names == "" {
+		assert.Empty(t, s.config.Notifiers, "Expected no notifiers in config")
+		return t.err
+	}
+	assert.Equal(t, strings.Split(names, ","), s.config.Notifiers, "Unexpected notifiers in config")
+	return t.err
+}
+
+func (s *testState) theNotificationOutputShouldBe(output string) error {
+	t := &testingT{}
+	assert.True(t, s.notification.wasCalled, "Expected a notification to be sent, but it was not")
+	assert.Equal(t, strings.ReplaceAll(output, `\n`, "\n"), s.notification.event.Output, "Unexpected captured output")
+	return t.err
+}
+
+func (s *testState) theConfigFileContains(content *godog.DocString) error {
+	dir, err := os.MkdirTemp("", "nf-features-")
+	if err != nil {
+		return err
+	}
+	s.configFile = filepath.Join(dir, "config.toml")
+	return os.WriteFile(s.configFile, []byte(content.Content), 0o600)
+}
+
+func (s *testState) nfShouldExitWithCode(code int) error {
+	t := &testingT{}
+	if code == 0 {
+		assert.NoError(t, s.err, "Expected nf to succeed")
+		return t.err
+	}
+	var exitErr *cmd.ExitCodeError
+	if assert.ErrorAs(t, s.err, &exitErr, "Expected nf to exit with the command's exit code") {
+		assert.Equal(t, code, exitErr.Status.Code, "Unexpected exit code")
+	}
+	return t.err
+}
+
 // testingT is a dummy implementation of testing.T for use with testify
 type testingT struct {
 	err error
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Capture modes for the command's output.
+const (
+	captureNone     = "none"
+	captureStderr   = "stderr"
+	captureCombined = "combined"
+)
+
+// tailBuffer is an io.Writer that keeps only the last maxLines lines and at
+// most maxBytes bytes of what is written to it. It is safe for concurrent
+// use, so stdout and stderr can both be written to the same buffer.
+type tailBuffer struct {
+	mu       sync.Mutex
+	maxLines int
+	maxBytes int
+	lines    [][]byte // complete lines, oldest first, without newlines
+	size     int      // total length of lines
+	partial  []byte   // the current, unterminated line
+}
+
+// newTailBuffer creates a tailBuffer with the given limits. A limit of zero
+// or less means unlimited.
+func newTailBuffer(maxLines, maxBytes int) *tailBuffer {
+	return &tailBuffer{maxLines: maxLines, maxBytes: maxBytes}
+}
+
+// Write records p, discarding the oldest lines once a limit is exceeded.
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	n := len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			b.partial = append(b.partial, p...)
+			// A single huge line must not grow the buffer without bound.
+			if b.maxBytes > 0 && len(b.partial) > b.maxBytes {
+				b.partial = append(b.partial[:0], lastBytes(b.partial, b.maxBytes)...)
+			}
+			break
+		}
+		line := append(b.partial, p[:i]...)
+		b.partial = nil
+		b.addLine(bytes.TrimSuffix(line, []byte("\r")))
+		p = p[i+1:]
+	}
+	return n, nil
+}
+
+// addLine appends a complete line and drops old lines beyond the limits.
+func (b *tailBuffer) addLine(line []byte) {
+	if b.maxBytes > 0 && len(line) > b.maxBytes {
+		line = lastBytes(line, b.maxBytes)
+	}
+	b.lines = append(b.lines, line)
+	b.size += len(line)
+
+	for len(b.lines) > 0 && ((b.maxLines > 0 && len(b.lines) > b.maxLines) ||
+		(b.maxBytes > 0 && b.size+len(b.lines)-1 > b.maxBytes)) {
+		b.size -= len(b.lines[0])
+		b.lines = b.lines[1:]
+	}
+}
+
+// lastBytes returns the longest suffix of p that is at most maxBytes bytes
+// long and does not start in the middle of a UTF-8 sequence.
+func lastBytes(p []byte, maxBytes int) []byte {
+	start := len(p) - maxBytes
+	for start < len(p) && !utf8.RuneStart(p[start]) {
+		start++
+	}
+	return p[start:]
+}
+
+// String returns the retained output, including a trailing partial line.
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	lines := make([]string, 0, len(b.lines)+1)
+	for _, line := range b.lines {
+		lines = append(lines, string(line))
+	}
+	if len(b.partial) > 0 {
+		lines = append(lines, string(b.partial))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// LastLine returns the most recent line of output, preferring the current
+// partial line if the command is in the middle of writing one.
+func (b *tailBuffer) LastLine() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.partial) > 0 {
+		return string(b.partial)
+	}
+	if len(b.lines) > 0 {
+		return string(b.lines[len(b.lines)-1])
+	}
+	return ""
+}
+
+// captureWriters returns the extra writers the command's stdout and stderr
+// should be copied to for the configured capture mode, along with the
+// buffer that collects them. The buffer is nil if capture is disabled.
+func captureWriters(config Config) (stdout, stderr io.Writer, tail *tailBuffer, err error) {
+	switch strings.ToLower(config.Capture) {
+	case captureNone, "":
+		return nil, nil, nil, nil
+	case captureStderr:
+		tail = newTailBuffer(config.CaptureLines, config.CaptureBytes)
+		return nil, tail, tail, nil
+	case captureCombined:
+		tail = newTailBuffer(config.CaptureLines, config.CaptureBytes)
+		return tail, tail, tail, nil
+	default:
+		return nil, nil, nil, fmt.Errorf("unknown capture mode %q: expected none, stderr or combined", config.Capture)
+	}
+}
+
+// teeWriter returns a writer that writes to the terminal stream and, if
+// extra is non-nil, to extra as well. When there is nothing to copy to, the
+// terminal stream is returned unchanged so the command keeps writing to the
+// terminal directly rather than through a pipe.
+func teeWriter(terminal *os.File, extra io.Writer) io.Writer {
+	if extra == nil {
+		return terminal
+	}
+	return io.MultiWriter(terminal, extra)
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTailBuffer(t *testing.T) {
+	testCases := []struct {
+		name     string
+		maxLines int
+		maxBytes int
+		writes   []string
+		expected string
+	}{
+		{
+			name:     "keeps everything under the limits",
+			maxLines: 5,
+			maxBytes: 100,
+			writes:   []string{"one\ntwo\n", "three"},
+			expected: "one\ntwo\nthree",
+		},
+		{
+			name:     "keeps the last lines",
+			maxLines: 2,
+			maxBytes: 100,
+			writes:   []string{"one\ntwo\nthree\nfour\n"},
+			expected: "three\nfour",
+		},
+		{
+			name:     "joins lines split across writes",
+			maxLines: 2,
+			maxBytes: 100,
+			writes:   []string{"par", "tial\r\nnext", " line\n"},
+			expected: "partial\nnext line",
+		},
+		{
+			name:     "drops lines beyond the byte limit",
+			maxLines: 10,
+			maxBytes: 9,
+			writes:   []string{"aaaa\nbbbb\ncccc\n"},
+			expected: "bbbb\ncccc",
+		},
+		{
+			name:     "truncates a single long line",
+			maxLines: 10,
+			maxBytes: 4,
+			writes:   []string{strings.Repeat("x", 50) + "tail\n", "abcdefgh"},
+			expected: "tail\nefgh",
+		},
+		{
+			name:     "does not split multi-byte characters",
+			maxLines: 10,
+			maxBytes: 4,
+			writes:   []string{"ööö✓\n", "✓✓"},
+			expected: "✓\n✓",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := newTailBuffer(tc.maxLines, tc.maxBytes)
+			for _, w := range tc.writes {
+				n, err := b.Write([]byte(w))
+				assert.NoError(t, err)
+				assert.Equal(t, len(w), n)
+			}
+			assert.Equal(t, tc.expected, b.String())
+		})
+	}
+}
@@ -1,23 +1,249 @@
 package cmd
 
+import "time"
+
 // Config stores all configuration for the application.
 // The values are read by viper from a config file, environment variables, or flags.
 type Config struct {
 	// Threshold in seconds for sending a notification.
 	Threshold int `mapstructure:"threshold"`
 
-	// Notifier to use. e.g., "os", "slack", "teams", "app".
-	Notifier string `mapstructure:"notifier"`
+	// Notifiers to send to. e.g., ["os", "slack"]. A single string or a
+	// comma-separated list such as "os,slack" is also accepted.
+	Notifiers []string `mapstructure:"notifier"`
+
+	// NotifyTimeout is how long nf waits for the notifiers before giving up
+	// on the ones that have not answered. Zero means no limit.
+	NotifyTimeout time.Duration `mapstructure:"notify_timeout"`
 
 	// SlackWebhook is the webhook URL for Slack notifications.
 	SlackWebhook string `mapstructure:"slack_webhook"`
 
+	// SlackToken is a bot token used instead of the webhook to post to
+	// SlackChannel through the Web API at SlackAPIURL.
+	SlackToken   string `mapstructure:"slack_token"`
+	SlackChannel string `mapstructure:"slack_channel"`
+	SlackAPIURL  string `mapstructure:"slack_api_url"`
+
 	// TeamsWebhook is the webhook URL for Teams notifications.
 	TeamsWebhook string `mapstructure:"teams_webhook"`
 
+	// TeamsFormat is "adaptive" (the default) for Adaptive Cards, as used
+	// by Workflows webhooks, or "messagecard" for legacy connectors.
+	TeamsFormat string `mapstructure:"teams_format"`
+
+	// DiscordWebhook is the webhook URL for Discord notifications.
+	DiscordWebhook string `mapstructure:"discord_webhook"`
+
+	// DiscordUsername and DiscordAvatarURL override the name and picture
+	// the Discord webhook posts with.
+	DiscordUsername  string `mapstructure:"discord_username"`
+	DiscordAvatarURL string `mapstructure:"discord_avatar_url"`
+
+	// TelegramToken is the bot token for Telegram notifications, and
+	// TelegramChatID the chat the bot sends them to.
+	TelegramToken  string `mapstructure:"telegram_token"`
+	TelegramChatID string `mapstructure:"telegram_chat_id"`
+
+	// TelegramAPIURL overrides the Bot API server, e.g. for a self-hosted
+	// Bot API server.
+	TelegramAPIURL string `mapstructure:"telegram_api_url"`
+
+	// TelegramParseMode is "html" (the default) or "markdownv2".
+	TelegramParseMode string `mapstructure:"telegram_parse_mode"`
+
+	// TelegramSilentSuccess sends notifications about successful commands
+	// without a sound.
+	TelegramSilentSuccess bool `mapstructure:"telegram_silent_success"`
+
+	// TelegramAttachOutput uploads the captured output as a log file
+	// instead of including its tail in the message.
+	TelegramAttachOutput bool `mapstructure:"telegram_attach_output"`
+
+	// NtfyServer is the ntfy server to publish to (default https://ntfy.sh),
+	// and NtfyTopic the topic.
+	NtfyServer string `mapstructure:"ntfy_server"`
+	NtfyTopic  string `mapstructure:"ntfy_topic"`
+
+	// NtfyToken is an ntfy access token. NtfyUsername and NtfyPassword are
+	// used for basic authentication instead.
+	NtfyToken    string `mapstructure:"ntfy_token"`
+	NtfyUsername string `mapstructure:"ntfy_username"`
+	NtfyPassword string `mapstructure:"ntfy_password"`
+
+	// NtfyClick is a URL opened when the notification is tapped, and
+	// NtfyActions action buttons in ntfy's header format.
+	NtfyClick   string `mapstructure:"ntfy_click"`
+	NtfyActions string `mapstructure:"ntfy_actions"`
+
+	// GotifyURL is the Gotify server, GotifyToken the application token nf
+	// sends with, and GotifyClick a URL opened when the notification is
+	// clicked.
+	GotifyURL   string `mapstructure:"gotify_url"`
+	GotifyToken string `mapstructure:"gotify_token"`
+	GotifyClick string `mapstructure:"gotify_click"`
+
+	// PushoverToken is the Pushover application token and PushoverUser the
+	// user or group key to notify. PushoverDevice optionally limits
+	// delivery to some of the user's devices.
+	PushoverToken  string `mapstructure:"pushover_token"`
+	PushoverUser   string `mapstructure:"pushover_user"`
+	PushoverDevice string `mapstructure:"pushover_device"`
+
+	// PushoverAPIURL overrides the Pushover API server.
+	PushoverAPIURL string `mapstructure:"pushover_api_url"`
+
+	// PushoverURL and PushoverURLTitle add a link to notifications.
+	PushoverURL      string `mapstructure:"pushover_url"`
+	PushoverURLTitle string `mapstructure:"pushover_url_title"`
+
+	// PushoverSounds maps outcomes, e.g. "failed", to Pushover sounds.
+	PushoverSounds map[string]string `mapstructure:"pushover_sounds"`
+
+	// PushoverEmergency lists the outcomes sent with emergency priority,
+	// which repeat every PushoverRetry until acknowledged or until
+	// PushoverExpire has passed.
+	PushoverEmergency []string      `mapstructure:"pushover_emergency"`
+	PushoverRetry     time.Duration `mapstructure:"pushover_retry"`
+	PushoverExpire    time.Duration `mapstructure:"pushover_expire"`
+
+	// EmailHost and EmailPort are the SMTP server to send email
+	// notifications through. The port defaults to 587, or 465 with
+	// implicit TLS.
+	EmailHost string `mapstructure:"email_host"`
+	EmailPort int    `mapstructure:"email_port"`
+
+	// EmailSecurity is "starttls" (the default), "tls" or "none".
+	EmailSecurity string `mapstructure:"email_security"`
+
+	// EmailUsername and EmailPassword authenticate with the server using
+	// EmailAuth: "plain" (the default), "login" or "cram-md5".
+	EmailUsername string `mapstructure:"email_username"`
+	EmailPassword string `mapstructure:"email_password"`
+	EmailAuth     string `mapstructure:"email_auth"`
+
+	// EmailFrom is the sender address and EmailTo the recipients.
+	EmailFrom string   `mapstructure:"email_from"`
+	EmailTo   []string `mapstructure:"email_to"`
+
+	// GoogleChatWebhook is a Google Chat space webhook URL. GoogleChatLogURL
+	// adds a button linking to logs, and GoogleChatThread groups runs of
+	// the same command into one thread.
+	GoogleChatWebhook string `mapstructure:"googlechat_webhook"`
+	GoogleChatLogURL  string `mapstructure:"googlechat_log_url"`
+	GoogleChatThread  bool   `mapstructure:"googlechat_thread"`
+
+	// MattermostWebhook is a Mattermost incoming webhook URL.
+	// MattermostChannel overrides its channel, and MattermostUsername,
+	// MattermostIconURL and MattermostIconEmoji how posts are signed.
+	MattermostWebhook   string `mapstructure:"mattermost_webhook"`
+	MattermostChannel   string `mapstructure:"mattermost_channel"`
+	MattermostUsername  string `mapstructure:"mattermost_username"`
+	MattermostIconURL   string `mapstructure:"mattermost_icon_url"`
+	MattermostIconEmoji string `mapstructure:"mattermost_icon_emoji"`
+
+	// RocketChatWebhook is a Rocket.Chat incoming webhook URL.
+	// RocketChatChannel overrides its channel, and RocketChatAlias,
+	// RocketChatEmoji and RocketChatAvatar how messages are signed.
+	RocketChatWebhook string `mapstructure:"rocketchat_webhook"`
+	RocketChatChannel string `mapstructure:"rocketchat_channel"`
+	RocketChatAlias   string `mapstructure:"rocketchat_alias"`
+	RocketChatEmoji   string `mapstructure:"rocketchat_emoji"`
+	RocketChatAvatar  string `mapstructure:"rocketchat_avatar"`
+
+	// MatrixHomeserver is the Matrix homeserver, MatrixToken the access
+	// token of the account nf sends as, and MatrixRoom the ID of the room
+	// to send to. MatrixNoticeSuccess sends successes as m.notice messages.
+	MatrixHomeserver    string `mapstructure:"matrix_homeserver"`
+	MatrixToken         string `mapstructure:"matrix_token"`
+	MatrixRoom          string `mapstructure:"matrix_room"`
+	MatrixNoticeSuccess bool   `mapstructure:"matrix_notice_success"`
+
+	// WebhookURL is the endpoint of the generic webhook notifier, called
+	// with WebhookMethod (POST by default) and WebhookHeaders.
+	WebhookURL     string            `mapstructure:"webhook_url"`
+	WebhookMethod  string            `mapstructure:"webhook_method"`
+	WebhookHeaders map[string]string `mapstructure:"webhook_headers"`
+
+	// WebhookBody is a text/template for the request body, or
+	// WebhookBodyFile a file holding one. A JSON summary of the event is
+	// sent if neither is set.
+	WebhookBody     string `mapstructure:"webhook_body"`
+	WebhookBodyFile string `mapstructure:"webhook_body_file"`
+
+	// ExecCommand is the program, followed by its arguments, that the exec
+	// notifier runs for every notification, for at most ExecTimeout.
+	ExecCommand []string      `mapstructure:"exec_command"`
+	ExecTimeout time.Duration `mapstructure:"exec_timeout"`
+
+	// TerminalProtocols lists the escape sequences the terminal notifier
+	// sends (osc9, osc777, osc99, bel), detected from the environment if
+	// empty or "auto". TerminalTTY overrides the controlling terminal.
+	TerminalProtocols []string `mapstructure:"terminal_protocols"`
+	TerminalTTY       string   `mapstructure:"terminal_tty"`
+
+	// TmuxPane is the pane the tmux notifier flags, $TMUX_PANE by default.
+	// TmuxRename prefixes the window name with the outcome unless tmux names
+	// the window automatically, and TmuxBell rings the bell in the pane.
+	TmuxPane   string `mapstructure:"tmux_pane"`
+	TmuxRename bool   `mapstructure:"tmux_rename"`
+	TmuxBell   bool   `mapstructure:"tmux_bell"`
+
 	// APIURL is the endpoint for the dedicated app notifier backend.
 	APIURL string `mapstructure:"api_url"`
 
 	// APIToken is a bearer token for authenticating with the API.
 	APIToken string `mapstructure:"api_token"`
+
+	// Capture selects which output of the command is attached to the
+	// notification: "none", "stderr" or "combined".
+	Capture string `mapstructure:"capture"`
+
+	// CaptureLines is the maximum number of output lines to keep.
+	CaptureLines int `mapstructure:"capture_lines"`
+
+	// CaptureBytes is the maximum number of output bytes to keep.
+	CaptureBytes int `mapstructure:"capture_bytes"`
+
+	// KillGrace is how long to wait after forwarding a terminating signal to
+	// the command before killing it with SIGKILL. Zero waits indefinitely.
+	KillGrace time.Duration `mapstructure:"kill_grace"`
+
+	// Timeout stops the command once it has run for this long. Zero means
+	// no timeout.
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	// TimeoutSignal is the signal sent to the command when it times out,
+	// e.g. "TERM" or "INT".
+	TimeoutSignal string `mapstructure:"timeout_signal"`
+
+	// TimeoutGrace is how long to wait after TimeoutSignal before killing the
+	// command with SIGKILL. Zero waits indefinitely.
+	TimeoutGrace time.Duration `mapstructure:"timeout_grace"`
+
+	// HeartbeatInterval sends a "still running" notification every time the
+	// command has run for another interval. Zero disables it.
+	HeartbeatInterval time.Duration `mapstructure:"heartbeat_interval"`
+
+	// HeartbeatAt sends a "still running" notification once the command has
+	// run for each of these durations, e.g. ["30m", "1h", "4h"].
+	HeartbeatAt []time.Duration `mapstructure:"heartbeat_at"`
+
+	// HeartbeatExclude lists notifiers that never receive heartbeats.
+	HeartbeatExclude []string `mapstructure:"heartbeat_exclude"`
+
+	// OnMatch lists regular expressions that trigger a notification as soon
+	// as a line of the command's output matches, without waiting for it to exit.
+	OnMatch []string `mapstructure:"on_match"`
+
+	// OnMatchMode is "once" to notify only for the first match of each
+	// pattern, or "every" to notify for every matching line.
+	OnMatchMode string `mapstructure:"on_match_mode"`
+
+	// OnMatchInterval is the minimum time between match notifications.
+	OnMatchInterval time.Duration `mapstructure:"on_match_interval"`
+
+	// Rules route matching events to specific notifiers instead of the
+	// default ones. See Rule for the available conditions.
+	Rules []Rule `mapstructure:"rules"`
 }
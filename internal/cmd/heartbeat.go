@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/jules-labs/nf/internal/notifier"
+)
+
+// heartbeatSchedule decides when "still running" notifications are sent.
+// Heartbeats are sent at every multiple of Interval and at each of the
+// Milestones, whichever come first.
+type heartbeatSchedule struct {
+	Interval   time.Duration
+	Milestones []time.Duration
+}
+
+// enabled reports whether any heartbeats are scheduled.
+func (s heartbeatSchedule) enabled() bool {
+	return s.Interval > 0 || len(s.Milestones) > 0
+}
+
+// next returns the elapsed time at which the next heartbeat is due after
+// elapsed, or false if there are no more heartbeats.
+func (s heartbeatSchedule) next(elapsed time.Duration) (time.Duration, bool) {
+	var next time.Duration
+	found := false
+
+	if s.Interval > 0 {
+		next = (elapsed/s.Interval + 1) * s.Interval
+		found = true
+	}
+
+	milestones := append([]time.Duration(nil), s.Milestones...)
+	sort.Slice(milestones, func(i, j int) bool { return milestones[i] < milestones[j] })
+	for _, m := range milestones {
+		if m > elapsed {
+			if !found || m < next {
+				next = m
+				found = true
+			}
+			break
+		}
+	}
+
+	return next, found
+}
+
+// startHeartbeats sends a "still running" notification for args on the
+// schedule until the returned stop function is called. If tail is not nil,
+// each heartbeat includes the latest line of captured output.
+func startHeartbeats(args []string, schedule heartbeatSchedule, tail *tailBuffer) (stop func()) {
+	if !schedule.enabled() {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	startTime := time.Now()
+
+	go func() {
+		defer close(finished)
+		elapsed := time.Duration(0)
+		for {
+			due, ok := schedule.next(elapsed)
+			if !ok {
+				return
+			}
+			timer := time.NewTimer(due - time.Since(startTime))
+			select {
+			case <-done:
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+			elapsed = due
+
+			event := runningEvent(args, startTime, tail)
+			fmt.Fprintf(os.Stderr, "nf: Command still running after %s. Sending heartbeat...\n", due)
+			if err := dispatch(event, cfg.HeartbeatExclude); err != nil {
+				fmt.Fprintf(os.Stderr, "nf: %v\n", err)
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-finished
+	}
+}
+
+// runningEvent describes args as still running since startTime. If tail is
+// not nil, the event includes the latest line of captured output.
+func runningEvent(args []string, startTime time.Time, tail *tailBuffer) notifier.Event {
+	event := newEvent(args, time.Since(startTime), ExitStatus{})
+	event.Outcome = notifier.OutcomeRunning
+	event.EndTime = time.Time{}
+	if tail != nil {
+		event.Output = tail.LastLine()
+	}
+	return event
+}
+
+// startSlackRun posts the "running" message that the Slack notifier updates
+// with the result when it uses a bot token, even if no heartbeats are
+// scheduled. The message is posted once the command has run for the
+// threshold, so that commands too short to be notified about do not leave a
+// message behind, and only if the rules route a heartbeat to Slack. The
+// returned stop function cancels the message if it is not due yet.
+func startSlackRun(args []string, tail *tailBuffer) (stop func()) {
+	if cfg.SlackToken == "" {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	startTime := time.Now()
+
+	go func() {
+		defer close(finished)
+		timer := time.NewTimer(time.Duration(cfg.Threshold) * time.Second)
+		select {
+		case <-done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		event := runningEvent(args, startTime, tail)
+		names, _, err := routeNotifiers(cfg, event)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "nf: failed to evaluate rules: %v\n", err)
+			return
+		}
+		if !containsString(names, "slack") || containsString(cfg.HeartbeatExclude, "slack") {
+			return
+		}
+
+		fmt.Fprintln(os.Stderr, "nf: Command still running. Posting Slack start message...")
+		slackCfg := cfg
+		slackCfg.Notifiers = []string{"slack"}
+		n, err := GetNotifier(slackCfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "nf: failed to get notifier: %v\n", err)
+			return
+		}
+		if err := sendNotification(n, event); err != nil {
+			fmt.Fprintf(os.Stderr, "nf: %v\n", err)
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-finished
+	}
+}
+
+// finishSlackRun updates the Slack "running" message about the command
+// with its result if the result itself was not sent to Slack, e.g. because
+// no rule routes it there or the command finished below the threshold after
+// an early heartbeat. Otherwise the message would claim the command is
+// still running forever.
+func finishSlackRun(event notifier.Event) {
+	if cfg.SlackToken == "" || cfg.SlackChannel == "" {
+		return
+	}
+	n, err := newNotifierByName("slack", cfg)
+	if err != nil {
+		return
+	}
+	slack, ok := n.(*notifier.SlackNotifier)
+	if !ok {
+		return
+	}
+
+	ctx := context.Background()
+	if cfg.NotifyTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.NotifyTimeout)
+		defer cancel()
+	}
+	if err := slack.FinishRun(ctx, event); err != nil {
+		fmt.Fprintf(os.Stderr, "nf: %v\n", err)
+	}
+}
@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jules-labs/nf/internal/notifier"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeartbeatSchedule_Next(t *testing.T) {
+	testCases := []struct {
+		name     string
+		schedule heartbeatSchedule
+		elapsed  time.Duration
+		expected time.Duration
+		ok       bool
+	}{
+		{name: "disabled", schedule: heartbeatSchedule{}, elapsed: 0, ok: false},
+		{name: "first interval", schedule: heartbeatSchedule{Interval: time.Hour}, elapsed: 0, expected: time.Hour, ok: true},
+		{name: "later interval", schedule: heartbeatSchedule{Interval: time.Hour}, elapsed: time.Hour, expected: 2 * time.Hour, ok: true},
+		{name: "unsorted milestones", schedule: heartbeatSchedule{Milestones: []time.Duration{2 * time.Hour, 30 * time.Minute}}, elapsed: 30 * time.Minute, expected: 2 * time.Hour, ok: true},
+		{name: "past the last milestone", schedule: heartbeatSchedule{Milestones: []time.Duration{time.Hour}}, elapsed: time.Hour, ok: false},
+		{name: "milestone before interval", schedule: heartbeatSchedule{Interval: time.Hour, Milestones: []time.Duration{10 * time.Minute}}, elapsed: 0, expected: 10 * time.Minute, ok: true},
+		{name: "interval before milestone", schedule: heartbeatSchedule{Interval: time.Hour, Milestones: []time.Duration{90 * time.Minute}}, elapsed: 10 * time.Minute, expected: time.Hour, ok: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			next, ok := tc.schedule.next(tc.elapsed)
+			assert.Equal(t, tc.ok, ok)
+			if tc.ok {
+				assert.Equal(t, tc.expected, next)
+			}
+		})
+	}
+}
+
+// recordingNotifier records the events it is sent, for testing.
+type recordingNotifier struct {
+	mu     sync.Mutex
+	config Config
+	events []notifier.Event
+}
+
+func (r *recordingNotifier) Send(ctx context.Context, event notifier.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+func TestStartHeartbeats(t *testing.T) {
+	originalCfg, originalGetter := cfg, GetNotifier
+	defer func() { cfg, GetNotifier = originalCfg, originalGetter }()
+
+	recorder := &recordingNotifier{}
+	GetNotifier = func(config Config) (notifier.Notifier, error) {
+		recorder.mu.Lock()
+		recorder.config = config
+		recorder.mu.Unlock()
+		return recorder, nil
+	}
+	cfg = Config{Notifiers: []string{"os", "teams"}, HeartbeatExclude: []string{"teams"}}
+
+	tail := newTailBuffer(10, 1000)
+	tail.Write([]byte("step 1\nstep 2\n"))
+
+	stop := startHeartbeats([]string{"make", "all"}, heartbeatSchedule{Interval: 20 * time.Millisecond}, tail)
+	time.Sleep(110 * time.Millisecond)
+	stop()
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	require.GreaterOrEqual(t, len(recorder.events), 2, "Expected at least two heartbeats")
+	assert.Equal(t, []string{"os"}, recorder.config.Notifiers, "Expected teams to be excluded from heartbeats")
+
+	event := recorder.events[0]
+	assert.Equal(t, notifier.OutcomeRunning, event.Outcome)
+	assert.Equal(t, "Command Still Running: make", event.Title())
+	assert.Equal(t, "step 2", event.Output)
+	assert.GreaterOrEqual(t, event.Duration, 20*time.Millisecond)
+
+	// No more heartbeats are sent once stopped.
+	count := len(recorder.events)
+	recorder.mu.Unlock()
+	time.Sleep(50 * time.Millisecond)
+	recorder.mu.Lock()
+	assert.Equal(t, count, len(recorder.events))
+}
+
+// fakeSlackAPI answers Slack Web API calls and records which methods were
+// called.
+func fakeSlackAPI(t *testing.T) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			TS string `json:"ts"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		mu.Lock()
+		methods = append(methods, strings.TrimPrefix(r.URL.Path, "/"))
+		mu.Unlock()
+		ts := payload.TS
+		if ts == "" {
+			ts = "1700000000.000001"
+		}
+		json.NewEncoder(w).Encode(map[string]any{"ok": true, "channel": "C123", "ts": ts})
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), methods...)
+	}
+}
+
+func TestStartSlackRun_WithoutHeartbeats(t *testing.T) {
+	originalCfg := cfg
+	defer func() { cfg = originalCfg }()
+
+	server, called := fakeSlackAPI(t)
+	cfg = Config{
+		Notifiers:    []string{"slack"},
+		SlackToken:   "xoxb-token",
+		SlackChannel: "#builds",
+		SlackAPIURL:  server.URL,
+	}
+
+	args := []string{"make", "all"}
+	stop := startSlackRun(args, nil)
+	require.Eventually(t, func() bool { return len(called()) == 1 }, 5*time.Second, 10*time.Millisecond,
+		"Expected the start message to be posted without heartbeats")
+	stop()
+
+	event := newEvent(args, time.Second, ExitStatus{})
+	require.NoError(t, notifyIfOverThreshold(event))
+	finishSlackRun(event)
+	assert.Equal(t, []string{"chat.postMessage", "chat.update", "chat.postMessage"}, called(),
+		"Expected the final notification to update the start message")
+}
+
+func TestStartSlackRun_FollowsRules(t *testing.T) {
+	originalCfg := cfg
+	defer func() { cfg = originalCfg }()
+
+	server, called := fakeSlackAPI(t)
+	cfg = Config{
+		SlackToken:   "xoxb-token",
+		SlackChannel: "#builds",
+		SlackAPIURL:  server.URL,
+		Rules: []Rule{
+			{Command: "terraform *", Outcomes: []string{"running", "failed"}, Notifiers: []string{"slack"}},
+		},
+	}
+
+	// No rule sends make to Slack, so nothing is posted about it.
+	stop := startSlackRun([]string{"make"}, nil)
+	time.Sleep(50 * time.Millisecond)
+	stop()
+	assert.Empty(t, called())
+
+	// Heartbeats about terraform go to Slack but its success does not, so
+	// the start message is updated without posting the result.
+	args := []string{"terraform", "apply"}
+	stop = startSlackRun(args, nil)
+	require.Eventually(t, func() bool { return len(called()) == 1 }, 5*time.Second, 10*time.Millisecond,
+		"Expected the start message to be posted")
+	stop()
+
+	event := newEvent(args, time.Second, ExitStatus{})
+	require.NoError(t, notifyIfOverThreshold(event))
+	finishSlackRun(event)
+	assert.Equal(t, []string{"chat.postMessage", "chat.update"}, called())
+}
+
+func TestStartSlackRun_BelowThreshold(t *testing.T) {
+	originalCfg, originalGetter := cfg, GetNotifier
+	defer func() { cfg, GetNotifier = originalCfg, originalGetter }()
+
+	recorder := &recordingNotifier{}
+	GetNotifier = func(config Config) (notifier.Notifier, error) {
+		return recorder, nil
+	}
+	cfg = Config{Notifiers: []string{"slack"}, SlackToken: "xoxb-token", Threshold: 60}
+
+	startSlackRun([]string{"true"}, nil)()
+
+	cfg.SlackToken = ""
+	cfg.Threshold = 0
+	stop := startSlackRun([]string{"true"}, nil)
+	time.Sleep(50 * time.Millisecond)
+	stop()
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	assert.Empty(t, recorder.events, "Expected no start message for short commands or without a bot token")
+}
@@ -2,8 +2,11 @@ package cmd
 
 import (
 	"fmt"
-	"strings"
+	"os"
+	"strconv"
+	"time"
 
+	"github.com/jules-labs/nf/internal/notifier"
 	"github.com/spf13/cobra"
 )
 
@@ -22,29 +25,27 @@ func newInternalNotifyCmd() *cobra.Command {
 			// This is important because this command runs in a new process.
 			initConfig()
 
-			theNotifier, err := GetNotifier(cfg)
+			seconds, err := strconv.ParseFloat(duration, 64)
 			if err != nil {
-				return fmt.Errorf("failed to get notifier: %w", err)
+				return fmt.Errorf("invalid duration %q: %w", duration, err)
 			}
 
-			title := "Command Finished"
-			// The command string might be long, so we can truncate it.
-			if len(command) > 50 {
-				title = fmt.Sprintf("Command Finished: %s...", command[:50])
-			} else if len(command) > 0 {
-				title = fmt.Sprintf("Command Finished: %s", command)
+			// The shell hook only knows the command line, not its exit status.
+			event := notifier.Event{
+				Command:  command,
+				Outcome:  notifier.OutcomeUnknown,
+				Duration: time.Duration(seconds * float64(time.Second)),
+				EndTime:  time.Now(),
 			}
-
-			message := fmt.Sprintf("Command `%s` finished in %s seconds.", command, duration)
-
-			err = theNotifier.Notify(title, message)
-			if err != nil {
-				// Since this runs in the background, we can't easily show the user.
-				// Logging to a file would be an option for a more robust solution.
-				return fmt.Errorf("failed to send notification: %w", err)
-			}
-
-			return nil
+			event.StartTime = event.EndTime.Add(-event.Duration)
+			event.Host, _ = os.Hostname()
+			event.Dir, _ = os.Getwd()
+			event.User = currentUser()
+
+			// Since this runs in the background, we can't easily show the user
+			// a failure. Logging to a file would be an option for a more
+			// robust solution.
+			return dispatch(event, nil)
 		},
 	}
 
@@ -0,0 +1,10 @@
+//go:build freebsd || netbsd || openbsd || dragonfly
+
+package cmd
+
+// childStopped always reports false on the BSDs, where nf cannot tell a
+// stopped child from a running one without reaping it. Ctrl-Z then stops
+// only the command.
+func childStopped(pid int) bool {
+	return false
+}
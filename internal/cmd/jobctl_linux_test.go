@@ -0,0 +1,133 @@
+//go:build linux
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestRunCommand_JobControlHelper runs nf's RunCommand for
+// TestRunCommand_JobControl, in a process started from an interactive shell.
+func TestRunCommand_JobControlHelper(t *testing.T) {
+	if os.Getenv("NF_JOBCONTROL_HELPER") != "1" {
+		t.Skip("only run by TestRunCommand_JobControl")
+	}
+	_, status, err := RunCommand([]string{"sleep", "20"}, RunOptions{})
+	if err != nil {
+		os.Exit(1)
+	}
+	os.Exit(status.Code)
+}
+
+func TestRunCommand_JobControl(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not installed")
+	}
+	master, slave, err := openPTY()
+	if err != nil {
+		t.Skipf("no pseudo-terminal available: %v", err)
+	}
+	defer master.Close()
+
+	shell := exec.Command(bash, "--norc", "--noprofile", "-i")
+	shell.Env = append(os.Environ(), "PS1=$ ", "NF_JOBCONTROL_HELPER=1")
+	shell.Stdin, shell.Stdout, shell.Stderr = slave, slave, slave
+	shell.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+	require.NoError(t, shell.Start())
+	slave.Close()
+	defer shell.Process.Kill()
+
+	var mu sync.Mutex
+	var output bytes.Buffer
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := master.Read(buf)
+			mu.Lock()
+			output.Write(buf[:n])
+			mu.Unlock()
+			if err != nil {
+				return
+			}
+		}
+	}()
+	// waitFor waits until the terminal shows s and discards the output so far.
+	waitFor := func(s string) {
+		t.Helper()
+		deadline := time.Now().Add(10 * time.Second)
+		for {
+			mu.Lock()
+			i := strings.Index(output.String(), s)
+			if i >= 0 {
+				output.Next(i + len(s))
+			}
+			seen := output.String()
+			mu.Unlock()
+			if i >= 0 {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected the terminal to show %q, got %q", s, seen)
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+	// The terminal echoes what is typed, so the commands below print
+	// something different from how they are typed.
+	fmt.Fprintf(master, "%s -test.run='^TestRunCommand_JobControlHelper$'\n", os.Args[0])
+	waitFor("nf: Running command: sleep 20")
+	time.Sleep(200 * time.Millisecond)
+
+	// Ctrl-Z suspends the job and gives the terminal back to the shell.
+	// Typed input is discarded when the signal is sent, so each command is
+	// only typed once the previous step is visible.
+	master.Write([]byte{0x1a})
+	waitFor("Stopped")
+	fmt.Fprintln(master, "echo ALIVE$((1+1))")
+	waitFor("ALIVE2")
+
+	// fg resumes the command in the foreground, where Ctrl-C reaches it.
+	fmt.Fprintln(master, "fg")
+	waitFor("TestRunCommand_JobControlHelper$'")
+	time.Sleep(200 * time.Millisecond)
+	master.Write([]byte{0x03})
+	waitFor("^C")
+	fmt.Fprintln(master, "echo EXIT=$?")
+	waitFor("EXIT=130")
+}
+
+// openPTY opens a new pseudo-terminal pair.
+func openPTY() (master, slave *os.File, err error) {
+	master, err = os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	var unlock int32
+	var n uint32
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); errno != 0 {
+		master.Close()
+		return nil, nil, errno
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCGPTN, uintptr(unsafe.Pointer(&n))); errno != 0 {
+		master.Close()
+		return nil, nil, errno
+	}
+	slave, err = os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	return master, slave, nil
+}
@@ -0,0 +1,22 @@
+//go:build linux || darwin
+
+package cmd
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// pPID selects a single process in waitid.
+const pPID = 1
+
+// childStopped reports whether the child pid has been stopped, e.g. by
+// Ctrl-Z. Only stops are waited for, so an exited child is never reaped
+// here, which would leave exec.Cmd.Wait without an exit status.
+func childStopped(pid int) bool {
+	// Large enough for siginfo_t, whose first field is the signal number.
+	var info [128]byte
+	_, _, errno := syscall.Syscall6(syscall.SYS_WAITID, pPID, uintptr(pid), uintptr(unsafe.Pointer(&info[0])), syscall.WSTOPPED|syscall.WNOHANG, 0, 0)
+	// With WNOHANG, the signal number stays zero if the child has not stopped.
+	return errno == 0 && *(*int32)(unsafe.Pointer(&info[0])) == int32(syscall.SIGCHLD)
+}
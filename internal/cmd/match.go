@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jules-labs/nf/internal/notifier"
+)
+
+// Match modes for output pattern triggers.
+const (
+	matchOnce  = "once"
+	matchEvery = "every"
+)
+
+// maxMatchLineLength limits how much of a single unterminated line is kept
+// for matching, so a command printing without newlines cannot exhaust memory.
+const maxMatchLineLength = 4096
+
+// outputMatch is a line of output that matched one of the patterns.
+type outputMatch struct {
+	pattern string
+	line    string
+	at      time.Time
+}
+
+// matchTrigger watches the command's output for patterns and sends a
+// notification as soon as one appears, without waiting for the command to
+// exit.
+type matchTrigger struct {
+	patterns []*regexp.Regexp
+	every    bool
+	interval time.Duration
+
+	mu    sync.Mutex
+	fired map[int]bool
+	last  time.Time
+
+	matches chan outputMatch
+	done    chan struct{}
+}
+
+// newMatchTrigger creates a matchTrigger from the on_match settings. It
+// returns nil if no patterns are configured.
+func newMatchTrigger(config Config) (*matchTrigger, error) {
+	if len(config.OnMatch) == 0 {
+		return nil, nil
+	}
+
+	t := &matchTrigger{
+		interval: config.OnMatchInterval,
+		fired:    make(map[int]bool),
+		// Matches are queued so that writing output never waits on a
+		// notification being sent; excess matches are dropped.
+		matches: make(chan outputMatch, 16),
+		done:    make(chan struct{}),
+	}
+
+	switch strings.ToLower(config.OnMatchMode) {
+	case matchOnce, "":
+	case matchEvery:
+		t.every = true
+	default:
+		return nil, fmt.Errorf("unknown on_match_mode %q: expected once or every", config.OnMatchMode)
+	}
+
+	for _, pattern := range config.OnMatch {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid on_match pattern %q: %w", pattern, err)
+		}
+		t.patterns = append(t.patterns, re)
+	}
+	return t, nil
+}
+
+// writer returns an io.Writer that scans one output stream line by line.
+// Each stream needs its own writer so that lines from stdout and stderr are
+// not mixed up.
+func (t *matchTrigger) writer() io.Writer {
+	return &matchWriter{trigger: t}
+}
+
+// check records a match if line matches a pattern that may still fire, and
+// reports whether it did.
+func (t *matchTrigger) check(line []byte) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i, re := range t.patterns {
+		if !t.every && t.fired[i] {
+			continue
+		}
+		if !re.Match(line) {
+			continue
+		}
+
+		now := time.Now()
+		if t.interval > 0 && !t.last.IsZero() && now.Sub(t.last) < t.interval {
+			// Rate limited. In once mode the pattern may still fire later.
+			return true
+		}
+		t.fired[i] = true
+		t.last = now
+
+		select {
+		case t.matches <- outputMatch{pattern: re.String(), line: string(line), at: now}:
+		default:
+		}
+		return true
+	}
+	return false
+}
+
+// start sends a notification for each match until the returned stop
+// function is called. Matches that are already queued when stop is called
+// are still sent, so a match just before the command exits is not lost.
+func (t *matchTrigger) start(args []string) (stop func()) {
+	finished := make(chan struct{})
+	startTime := time.Now()
+
+	send := func(m outputMatch) {
+		event := newEvent(args, m.at.Sub(startTime), ExitStatus{})
+		event.Outcome = notifier.OutcomeMatched
+		event.EndTime = time.Time{}
+		event.Pattern = m.pattern
+		event.Output = m.line
+
+		fmt.Fprintf(os.Stderr, "nf: Output matched %q. Sending notification...\n", m.pattern)
+		if err := dispatch(event, nil); err != nil {
+			fmt.Fprintf(os.Stderr, "nf: %v\n", err)
+		}
+	}
+
+	go func() {
+		defer close(finished)
+		for {
+			select {
+			case m := <-t.matches:
+				send(m)
+			case <-t.done:
+				for {
+					select {
+					case m := <-t.matches:
+						send(m)
+					default:
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(t.done)
+		<-finished
+	}
+}
+
+// matchWriter splits a single output stream into lines for a matchTrigger.
+// An unterminated line is checked as well, because prompts such as
+// "password:" wait for input without printing a newline.
+type matchWriter struct {
+	trigger        *matchTrigger
+	partial        []byte
+	partialMatched bool
+}
+
+// Write scans p for matching lines. It never fails, so that output keeps
+// flowing to the terminal.
+func (w *matchWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			w.partial = append(w.partial, p...)
+			if len(w.partial) > maxMatchLineLength {
+				w.partial = append(w.partial[:0], w.partial[len(w.partial)-maxMatchLineLength:]...)
+			}
+			break
+		}
+		line := bytes.TrimSuffix(append(w.partial, p[:i]...), []byte("\r"))
+		if !w.partialMatched {
+			w.trigger.check(line)
+		}
+		w.partial = w.partial[:0]
+		w.partialMatched = false
+		p = p[i+1:]
+	}
+
+	if len(w.partial) > 0 && !w.partialMatched {
+		w.partialMatched = w.trigger.check(w.partial)
+	}
+	return n, nil
+}
+
+// combineWriters returns a writer that writes to every non-nil writer, or
+// nil if there are none.
+func combineWriters(writers ...io.Writer) io.Writer {
+	var nonNil []io.Writer
+	for _, w := range writers {
+		if w != nil {
+			nonNil = append(nonNil, w)
+		}
+	}
+	switch len(nonNil) {
+	case 0:
+		return nil
+	case 1:
+		return nonNil[0]
+	default:
+		return io.MultiWriter(nonNil...)
+	}
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/jules-labs/nf/internal/notifier"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// drainMatches returns the lines of all queued matches.
+func drainMatches(trigger *matchTrigger) []string {
+	var lines []string
+	for {
+		select {
+		case m := <-trigger.matches:
+			lines = append(lines, m.line)
+		default:
+			return lines
+		}
+	}
+}
+
+func TestMatchTrigger(t *testing.T) {
+	testCases := []struct {
+		name     string
+		config   Config
+		writes   []string
+		expected []string
+	}{
+		{
+			name:     "once",
+			config:   Config{OnMatch: []string{"error"}},
+			writes:   []string{"ok\nerror 1\nerror 2\n"},
+			expected: []string{"error 1"},
+		},
+		{
+			name:     "every",
+			config:   Config{OnMatch: []string{"error"}, OnMatchMode: "every"},
+			writes:   []string{"ok\nerror 1\nerror 2\n"},
+			expected: []string{"error 1", "error 2"},
+		},
+		{
+			name:     "once per pattern",
+			config:   Config{OnMatch: []string{"error", "warning"}},
+			writes:   []string{"warning 1\nerror 1\nwarning 2\n"},
+			expected: []string{"warning 1", "error 1"},
+		},
+		{
+			name:     "rate limited",
+			config:   Config{OnMatch: []string{"error"}, OnMatchMode: "every", OnMatchInterval: time.Hour},
+			writes:   []string{"error 1\nerror 2\n"},
+			expected: []string{"error 1"},
+		},
+		{
+			name:     "line split across writes",
+			config:   Config{OnMatch: []string{"^done$"}},
+			writes:   []string{"do", "ne\r\n"},
+			expected: []string{"done"},
+		},
+		{
+			name:     "prompt without newline",
+			config:   Config{OnMatch: []string{"[Pp]assword:"}, OnMatchMode: "every"},
+			writes:   []string{"Password:", " ", "\n"},
+			expected: []string{"Password:"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			trigger, err := newMatchTrigger(tc.config)
+			require.NoError(t, err)
+			w := trigger.writer()
+			for _, s := range tc.writes {
+				n, err := w.Write([]byte(s))
+				require.NoError(t, err)
+				assert.Equal(t, len(s), n)
+			}
+			assert.Equal(t, tc.expected, drainMatches(trigger))
+		})
+	}
+}
+
+func TestNewMatchTrigger_Errors(t *testing.T) {
+	trigger, err := newMatchTrigger(Config{})
+	assert.NoError(t, err)
+	assert.Nil(t, trigger, "Expected no trigger without patterns")
+
+	_, err = newMatchTrigger(Config{OnMatch: []string{"("}})
+	assert.ErrorContains(t, err, "invalid on_match pattern")
+
+	_, err = newMatchTrigger(Config{OnMatch: []string{"x"}, OnMatchMode: "sometimes"})
+	assert.ErrorContains(t, err, "unknown on_match_mode")
+}
+
+func TestMatchTrigger_Start(t *testing.T) {
+	originalGetter := GetNotifier
+	defer func() { GetNotifier = originalGetter }()
+
+	recorder := &recordingNotifier{}
+	GetNotifier = func(config Config) (notifier.Notifier, error) {
+		return recorder, nil
+	}
+
+	trigger, err := newMatchTrigger(Config{OnMatch: []string{"ready"}})
+	require.NoError(t, err)
+	stop := trigger.start([]string{"server", "--port", "80"})
+	io.WriteString(trigger.writer(), "starting\nserver ready on :80\n")
+	stop()
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	require.Len(t, recorder.events, 1)
+	event := recorder.events[0]
+	assert.Equal(t, notifier.OutcomeMatched, event.Outcome)
+	assert.Equal(t, "ready", event.Pattern)
+	assert.Equal(t, "server ready on :80", event.Output)
+	assert.Equal(t, "Output Matched: server", event.Title())
+	assert.False(t, event.HasExitCode())
+}
@@ -0,0 +1,387 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jules-labs/nf/internal/notifier"
+)
+
+// newNotifier returns the notifier for the configured notifier names. When
+// more than one is configured, they are combined into a MultiNotifier that
+// sends to all of them at once.
+// It lives in this package rather than in notifier so that the notifier
+// package does not need to import cmd for the Config type.
+func newNotifier(config Config) (notifier.Notifier, error) {
+	multi := notifier.NewMultiNotifier()
+	var single notifier.Notifier
+	var errs []error
+	seen := make(map[string]bool)
+	count := 0
+
+	for _, name := range config.Notifiers {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || name == "none" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		n, err := newNotifierByName(name, config)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		multi.Add(name, n)
+		single = n
+		count++
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	switch count {
+	case 0: // Also allow disabling notifications explicitly
+		return &notifier.NoOpNotifier{}, nil
+	case 1:
+		return single, nil
+	default:
+		return multi, nil
+	}
+}
+
+// newNotifierByName returns the notifier with the given name.
+func newNotifierByName(name string, config Config) (notifier.Notifier, error) {
+	switch name {
+	case "os":
+		return &notifier.OSNotifier{}, nil
+	case "slack":
+		if config.SlackToken != "" {
+			if config.SlackChannel == "" {
+				return nil, fmt.Errorf("slack notifier selected with a bot token but no channel provided (set NF_SLACK_CHANNEL)")
+			}
+			n := notifier.NewSlackBotNotifier(config.SlackToken, config.SlackChannel)
+			if config.SlackAPIURL != "" {
+				n.BaseURL = config.SlackAPIURL
+			}
+			return n, nil
+		}
+		if config.SlackWebhook == "" {
+			return nil, fmt.Errorf("slack notifier selected but no webhook URL or bot token provided (set NF_SLACK_WEBHOOK or NF_SLACK_TOKEN)")
+		}
+		return notifier.NewSlackNotifier(config.SlackWebhook), nil
+	case "teams":
+		if config.TeamsWebhook == "" {
+			return nil, fmt.Errorf("teams notifier selected but no webhook URL provided (set NF_TEAMS_WEBHOOK)")
+		}
+		n := notifier.NewTeamsNotifier(config.TeamsWebhook)
+		switch strings.ToLower(config.TeamsFormat) {
+		case "", notifier.TeamsAdaptiveCard:
+			n.Format = notifier.TeamsAdaptiveCard
+		case notifier.TeamsMessageCard:
+			n.Format = notifier.TeamsMessageCard
+		default:
+			return nil, fmt.Errorf("unknown teams_format %q: expected adaptive or messagecard", config.TeamsFormat)
+		}
+		return n, nil
+	case "discord":
+		if config.DiscordWebhook == "" {
+			return nil, fmt.Errorf("discord notifier selected but no webhook URL provided (set NF_DISCORD_WEBHOOK)")
+		}
+		return notifier.NewDiscordNotifier(config.DiscordWebhook, config.DiscordUsername, config.DiscordAvatarURL), nil
+	case "telegram":
+		return newTelegramNotifier(config)
+	case "ntfy":
+		if config.NtfyTopic == "" {
+			return nil, fmt.Errorf("ntfy notifier selected but no topic provided (set NF_NTFY_TOPIC)")
+		}
+		n := notifier.NewNtfyNotifier(config.NtfyServer, config.NtfyTopic)
+		n.Token, n.Username, n.Password = config.NtfyToken, config.NtfyUsername, config.NtfyPassword
+		n.Click, n.Actions = config.NtfyClick, config.NtfyActions
+		return n, nil
+	case "gotify":
+		if config.GotifyURL == "" || config.GotifyToken == "" {
+			return nil, fmt.Errorf("gotify notifier selected but no server URL or app token provided (set NF_GOTIFY_URL and NF_GOTIFY_TOKEN)")
+		}
+		n := notifier.NewGotifyNotifier(config.GotifyURL, config.GotifyToken)
+		n.ClickURL = config.GotifyClick
+		return n, nil
+	case "pushover":
+		return newPushoverNotifier(config)
+	case "email":
+		return newEmailNotifier(config)
+	case "googlechat":
+		if config.GoogleChatWebhook == "" {
+			return nil, fmt.Errorf("googlechat notifier selected but no webhook URL provided (set NF_GOOGLECHAT_WEBHOOK)")
+		}
+		n := notifier.NewGoogleChatNotifier(config.GoogleChatWebhook)
+		n.LogURL, n.Thread = config.GoogleChatLogURL, config.GoogleChatThread
+		return n, nil
+	case "mattermost":
+		if config.MattermostWebhook == "" {
+			return nil, fmt.Errorf("mattermost notifier selected but no webhook URL provided (set NF_MATTERMOST_WEBHOOK)")
+		}
+		n := notifier.NewMattermostNotifier(config.MattermostWebhook)
+		n.Channel, n.Username = config.MattermostChannel, config.MattermostUsername
+		n.IconURL, n.IconEmoji = config.MattermostIconURL, config.MattermostIconEmoji
+		return n, nil
+	case "rocketchat":
+		if config.RocketChatWebhook == "" {
+			return nil, fmt.Errorf("rocketchat notifier selected but no webhook URL provided (set NF_ROCKETCHAT_WEBHOOK)")
+		}
+		n := notifier.NewRocketChatNotifier(config.RocketChatWebhook)
+		n.Channel, n.Alias = config.RocketChatChannel, config.RocketChatAlias
+		n.Emoji, n.Avatar = config.RocketChatEmoji, config.RocketChatAvatar
+		return n, nil
+	case "matrix":
+		if config.MatrixHomeserver == "" || config.MatrixToken == "" || config.MatrixRoom == "" {
+			return nil, fmt.Errorf("matrix notifier selected but no homeserver, access token or room provided (set NF_MATRIX_HOMESERVER, NF_MATRIX_TOKEN and NF_MATRIX_ROOM)")
+		}
+		n := notifier.NewMatrixNotifier(config.MatrixHomeserver, config.MatrixToken, config.MatrixRoom)
+		n.NoticeForSuccess = config.MatrixNoticeSuccess
+		return n, nil
+	case "webhook":
+		return newWebhookNotifier(config)
+	case "exec":
+		if len(config.ExecCommand) == 0 {
+			return nil, fmt.Errorf("exec notifier selected but no command provided (set NF_EXEC_COMMAND)")
+		}
+		n := notifier.NewExecNotifier(config.ExecCommand)
+		if config.ExecTimeout > 0 {
+			n.Timeout = config.ExecTimeout
+		}
+		return n, nil
+	case "terminal":
+		return newTerminalNotifier(config)
+	case "tmux":
+		n := notifier.NewTmuxNotifier()
+		n.Pane, n.Rename, n.Bell = config.TmuxPane, config.TmuxRename, config.TmuxBell
+		return n, nil
+	case "app":
+		if config.APIURL == "" {
+			return nil, fmt.Errorf("app notifier selected but no API URL provided (set NF_API_URL)")
+		}
+		return notifier.NewAppNotifier(config.APIURL, config.APIToken), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier: %s", name)
+	}
+}
+
+// newTelegramNotifier creates the Telegram notifier from the telegram_*
+// settings.
+func newTelegramNotifier(config Config) (notifier.Notifier, error) {
+	if config.TelegramToken == "" || config.TelegramChatID == "" {
+		return nil, fmt.Errorf("telegram notifier selected but no bot token or chat ID provided (set NF_TELEGRAM_TOKEN and NF_TELEGRAM_CHAT_ID)")
+	}
+
+	n := notifier.NewTelegramNotifier(config.TelegramToken, config.TelegramChatID)
+	if config.TelegramAPIURL != "" {
+		n.BaseURL = config.TelegramAPIURL
+	}
+	switch strings.ToLower(config.TelegramParseMode) {
+	case "", "html":
+		n.ParseMode = notifier.TelegramHTML
+	case "markdownv2":
+		n.ParseMode = notifier.TelegramMarkdownV2
+	default:
+		return nil, fmt.Errorf("unknown telegram_parse_mode %q: expected html or markdownv2", config.TelegramParseMode)
+	}
+	n.SilentSuccess = config.TelegramSilentSuccess
+	n.AttachOutput = config.TelegramAttachOutput
+	return n, nil
+}
+
+// newPushoverNotifier creates the Pushover notifier from the pushover_*
+// settings.
+func newPushoverNotifier(config Config) (notifier.Notifier, error) {
+	if config.PushoverToken == "" || config.PushoverUser == "" {
+		return nil, fmt.Errorf("pushover notifier selected but no app token or user key provided (set NF_PUSHOVER_TOKEN and NF_PUSHOVER_USER)")
+	}
+
+	n := notifier.NewPushoverNotifier(config.PushoverToken, config.PushoverUser)
+	if config.PushoverAPIURL != "" {
+		n.BaseURL = config.PushoverAPIURL
+	}
+	n.Device = config.PushoverDevice
+	n.URL, n.URLTitle = config.PushoverURL, config.PushoverURLTitle
+
+	for name, sound := range config.PushoverSounds {
+		outcome, err := parseOutcome(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pushover_sounds: %w", err)
+		}
+		if n.Sounds == nil {
+			n.Sounds = make(map[notifier.Outcome]string)
+		}
+		n.Sounds[outcome] = sound
+	}
+	for _, name := range config.PushoverEmergency {
+		outcome, err := parseOutcome(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pushover_emergency: %w", err)
+		}
+		n.Emergency = append(n.Emergency, outcome)
+	}
+
+	if config.PushoverRetry != 0 {
+		if config.PushoverRetry < notifier.PushoverMinRetry {
+			return nil, fmt.Errorf("pushover_retry must be at least %s", notifier.PushoverMinRetry)
+		}
+		n.Retry = config.PushoverRetry
+	}
+	if config.PushoverExpire != 0 {
+		if config.PushoverExpire > notifier.PushoverMaxExpire {
+			return nil, fmt.Errorf("pushover_expire must be at most %s", notifier.PushoverMaxExpire)
+		}
+		n.Expire = config.PushoverExpire
+	}
+	return n, nil
+}
+
+// newEmailNotifier creates the email notifier from the email_* settings.
+func newEmailNotifier(config Config) (notifier.Notifier, error) {
+	if config.EmailHost == "" || config.EmailFrom == "" || len(config.EmailTo) == 0 {
+		return nil, fmt.Errorf("email notifier selected but no SMTP host, sender or recipients provided (set NF_EMAIL_HOST, NF_EMAIL_FROM and NF_EMAIL_TO)")
+	}
+
+	n := notifier.NewEmailNotifier(config.EmailHost, config.EmailPort, config.EmailFrom, config.EmailTo)
+	switch security := strings.ToLower(config.EmailSecurity); security {
+	case "":
+	case notifier.EmailSecuritySTARTTLS, notifier.EmailSecurityTLS, notifier.EmailSecurityNone:
+		n.Security = security
+	default:
+		return nil, fmt.Errorf("unknown email_security %q: expected starttls, tls or none", config.EmailSecurity)
+	}
+	if n.Port == 0 {
+		n.Port = 587
+		if n.Security == notifier.EmailSecurityTLS {
+			n.Port = 465
+		}
+	}
+
+	switch auth := strings.ToLower(config.EmailAuth); auth {
+	case "":
+	case notifier.EmailAuthPlain, notifier.EmailAuthLogin, notifier.EmailAuthCRAMMD5:
+		n.Auth = auth
+	default:
+		return nil, fmt.Errorf("unknown email_auth %q: expected plain, login or cram-md5", config.EmailAuth)
+	}
+	n.Username, n.Password = config.EmailUsername, config.EmailPassword
+	return n, nil
+}
+
+// newWebhookNotifier creates the generic webhook notifier from the
+// webhook_* settings.
+func newWebhookNotifier(config Config) (notifier.Notifier, error) {
+	if config.WebhookURL == "" {
+		return nil, fmt.Errorf("webhook notifier selected but no URL provided (set NF_WEBHOOK_URL)")
+	}
+
+	body := config.WebhookBody
+	if config.WebhookBodyFile != "" {
+		if body != "" {
+			return nil, fmt.Errorf("webhook_body and webhook_body_file are both set")
+		}
+		data, err := os.ReadFile(config.WebhookBodyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read webhook_body_file: %w", err)
+		}
+		body = string(data)
+	}
+
+	n, err := notifier.NewWebhookNotifier(config.WebhookURL, config.WebhookMethod, body)
+	if err != nil {
+		return nil, err
+	}
+	n.Headers = config.WebhookHeaders
+	return n, nil
+}
+
+// newTerminalNotifier creates the terminal notifier from the terminal_*
+// settings.
+func newTerminalNotifier(config Config) (notifier.Notifier, error) {
+	n := notifier.NewTerminalNotifier()
+	n.TTY = config.TerminalTTY
+	for _, protocol := range config.TerminalProtocols {
+		switch protocol = strings.ToLower(strings.TrimSpace(protocol)); protocol {
+		case "", "auto":
+			// Leaving Protocols empty detects them.
+		case notifier.TerminalOSC9, notifier.TerminalOSC777, notifier.TerminalOSC99, notifier.TerminalBell:
+			n.Protocols = append(n.Protocols, protocol)
+		default:
+			return nil, fmt.Errorf("unknown terminal protocol %q: expected auto, osc9, osc777, osc99 or bel", protocol)
+		}
+	}
+	return n, nil
+}
+
+// dispatch routes the event through the configured rules and sends it to
+// the resulting notifiers, except those listed in exclude.
+func dispatch(event notifier.Event, exclude []string) error {
+	names, _, err := routeNotifiers(cfg, event)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate rules: %w", err)
+	}
+
+	routed := cfg
+	routed.Notifiers = nil
+	for _, name := range names {
+		if !containsString(exclude, name) {
+			routed.Notifiers = append(routed.Notifiers, name)
+		}
+	}
+	theNotifier, err := GetNotifier(routed)
+	if err != nil {
+		return fmt.Errorf("failed to get notifier: %w", err)
+	}
+
+	return sendNotification(theNotifier, event)
+}
+
+func containsString(values []string, v string) bool {
+	for _, value := range values {
+		if strings.EqualFold(strings.TrimSpace(value), strings.TrimSpace(v)) {
+			return true
+		}
+	}
+	return false
+}
+
+// sendNotification sends the event and reports the result on stderr. When
+// several notifiers are configured, the result of each one is reported. A
+// notifier that has not answered within notify_timeout counts as failed.
+func sendNotification(n notifier.Notifier, event notifier.Event) error {
+	ctx := context.Background()
+	if cfg.NotifyTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, cfg.NotifyTimeout, fmt.Errorf("no response after %s", cfg.NotifyTimeout))
+		defer cancel()
+	}
+
+	multi, ok := n.(*notifier.MultiNotifier)
+	if !ok {
+		if err := notifier.SendContext(ctx, n, event); err != nil {
+			return fmt.Errorf("failed to send notification: %w", err)
+		}
+		fmt.Fprintln(os.Stderr, "nf: Notification sent successfully.")
+		return nil
+	}
+
+	results := multi.SendAll(ctx, event)
+	failed := false
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Fprintf(os.Stderr, "nf: Failed to send notification via %s: %v\n", r.Name, r.Err)
+			failed = true
+		} else {
+			fmt.Fprintf(os.Stderr, "nf: Notification sent successfully via %s.\n", r.Name)
+		}
+	}
+	if failed {
+		return fmt.Errorf("failed to send notification: %w", &notifier.SendError{Results: results})
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jules-labs/nf/internal/notifier"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEmailNotifier(t *testing.T) {
+	base := Config{EmailHost: "smtp.example.com", EmailFrom: "nf@example.com", EmailTo: []string{"ops@example.com"}}
+
+	n, err := newEmailNotifier(base)
+	require.NoError(t, err)
+	email := n.(*notifier.EmailNotifier)
+	assert.Equal(t, 587, email.Port)
+	assert.Equal(t, notifier.EmailSecuritySTARTTLS, email.Security)
+	assert.Equal(t, notifier.EmailAuthPlain, email.Auth)
+
+	config := base
+	config.EmailSecurity = "TLS"
+	config.EmailAuth = "cram-md5"
+	n, err = newEmailNotifier(config)
+	require.NoError(t, err)
+	email = n.(*notifier.EmailNotifier)
+	assert.Equal(t, 465, email.Port)
+	assert.Equal(t, notifier.EmailSecurityTLS, email.Security)
+	assert.Equal(t, notifier.EmailAuthCRAMMD5, email.Auth)
+
+	config = base
+	config.EmailPort = 2525
+	config.EmailSecurity = "none"
+	n, err = newEmailNotifier(config)
+	require.NoError(t, err)
+	assert.Equal(t, 2525, n.(*notifier.EmailNotifier).Port)
+
+	testCases := []struct {
+		name     string
+		modify   func(*Config)
+		expected string
+	}{
+		{name: "missing recipients", modify: func(c *Config) { c.EmailTo = nil }, expected: "no SMTP host, sender or recipients"},
+		{name: "unknown security", modify: func(c *Config) { c.EmailSecurity = "ssl3" }, expected: "unknown email_security"},
+		{name: "unknown auth", modify: func(c *Config) { c.EmailAuth = "ntlm" }, expected: "unknown email_auth"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := base
+			tc.modify(&config)
+			_, err := newEmailNotifier(config)
+			assert.ErrorContains(t, err, tc.expected)
+		})
+	}
+}
+
+func TestNewPushoverNotifier(t *testing.T) {
+	base := Config{PushoverToken: "app-token", PushoverUser: "user-key"}
+
+	n, err := newPushoverNotifier(base)
+	require.NoError(t, err)
+	pushover := n.(*notifier.PushoverNotifier)
+	assert.Equal(t, notifier.DefaultPushoverAPIURL, pushover.BaseURL)
+	assert.Equal(t, notifier.PushoverDefaultRetry, pushover.Retry)
+
+	config := base
+	config.PushoverSounds = map[string]string{"failed": "siren"}
+	config.PushoverEmergency = []string{"Timed_Out"}
+	config.PushoverRetry = 2 * time.Minute
+	n, err = newPushoverNotifier(config)
+	require.NoError(t, err)
+	pushover = n.(*notifier.PushoverNotifier)
+	assert.Equal(t, map[notifier.Outcome]string{notifier.OutcomeFailed: "siren"}, pushover.Sounds)
+	assert.Equal(t, []notifier.Outcome{notifier.OutcomeTimedOut}, pushover.Emergency)
+	assert.Equal(t, 2*time.Minute, pushover.Retry)
+
+	testCases := []struct {
+		name     string
+		modify   func(*Config)
+		expected string
+	}{
+		{name: "missing user", modify: func(c *Config) { c.PushoverUser = "" }, expected: "no app token or user key"},
+		{name: "unknown sound outcome", modify: func(c *Config) { c.PushoverSounds = map[string]string{"crashed": "siren"} }, expected: "invalid pushover_sounds"},
+		{name: "unknown emergency outcome", modify: func(c *Config) { c.PushoverEmergency = []string{"crashed"} }, expected: "invalid pushover_emergency"},
+		{name: "retry too short", modify: func(c *Config) { c.PushoverRetry = 10 * time.Second }, expected: "pushover_retry must be at least 30s"},
+		{name: "expire too long", modify: func(c *Config) { c.PushoverExpire = 4 * time.Hour }, expected: "pushover_expire must be at most 3h0m0s"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := base
+			tc.modify(&config)
+			_, err := newPushoverNotifier(config)
+			assert.ErrorContains(t, err, tc.expected)
+		})
+	}
+}
+
+func TestNewWebhookNotifier(t *testing.T) {
+	bodyFile := filepath.Join(t.TempDir(), "body.tmpl")
+	require.NoError(t, os.WriteFile(bodyFile, []byte(`{"text": {{json .Title}}}`), 0o644))
+
+	n, err := newWebhookNotifier(Config{WebhookURL: "https://example.com/hook", WebhookBodyFile: bodyFile, WebhookHeaders: map[string]string{"x-token": "secret"}})
+	require.NoError(t, err)
+	webhook := n.(*notifier.WebhookNotifier)
+	assert.Equal(t, "POST", webhook.Method)
+	assert.Equal(t, map[string]string{"x-token": "secret"}, webhook.Headers)
+
+	testCases := []struct {
+		name     string
+		config   Config
+		expected string
+	}{
+		{name: "missing URL", config: Config{}, expected: "no URL provided"},
+		{name: "body and body file", config: Config{WebhookURL: "https://example.com/hook", WebhookBody: "{}", WebhookBodyFile: bodyFile}, expected: "both set"},
+		{name: "missing body file", config: Config{WebhookURL: "https://example.com/hook", WebhookBodyFile: bodyFile + ".missing"}, expected: "failed to read webhook_body_file"},
+		{name: "invalid template", config: Config{WebhookURL: "https://example.com/hook", WebhookBody: "{{.Title"}, expected: "invalid webhook body template"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := newWebhookNotifier(tc.config)
+			assert.ErrorContains(t, err, tc.expected)
+		})
+	}
+}
+
+func TestNewTerminalNotifier(t *testing.T) {
+	n, err := newTerminalNotifier(Config{})
+	require.NoError(t, err)
+	assert.Empty(t, n.(*notifier.TerminalNotifier).Protocols, "Expected protocols to be detected by default")
+
+	n, err = newTerminalNotifier(Config{TerminalProtocols: []string{"OSC9", " bel"}, TerminalTTY: "/dev/pts/3"})
+	require.NoError(t, err)
+	terminal := n.(*notifier.TerminalNotifier)
+	assert.Equal(t, []string{notifier.TerminalOSC9, notifier.TerminalBell}, terminal.Protocols)
+	assert.Equal(t, "/dev/pts/3", terminal.TTY)
+
+	_, err = newTerminalNotifier(Config{TerminalProtocols: []string{"osc1337"}})
+	assert.ErrorContains(t, err, `unknown terminal protocol "osc1337"`)
+}
+
+// hangingNotifier never answers and ignores its context, for testing.
+type hangingNotifier struct{}
+
+func (hangingNotifier) Send(ctx context.Context, event notifier.Event) error {
+	select {}
+}
+
+func TestSendNotification_Timeout(t *testing.T) {
+	originalCfg := cfg
+	defer func() { cfg = originalCfg }()
+	cfg = Config{NotifyTimeout: 50 * time.Millisecond}
+
+	multi := notifier.NewMultiNotifier()
+	multi.Add("stuck", hangingNotifier{})
+	multi.Add("ok", &recordingNotifier{})
+
+	err := sendNotification(multi, notifier.Event{Command: "make"})
+	require.Error(t, err)
+	var sendErr *notifier.SendError
+	require.ErrorAs(t, err, &sendErr)
+	require.Len(t, sendErr.Results, 2)
+	assert.EqualError(t, sendErr.Results[0].Err, "no response after 50ms")
+	assert.NoError(t, sendErr.Results[1].Err)
+
+	err = sendNotification(hangingNotifier{}, notifier.Event{Command: "make"})
+	assert.EqualError(t, err, "failed to send notification: no response after 50ms")
+}
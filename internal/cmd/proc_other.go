@@ -0,0 +1,35 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// forwardedSignals are the signals nf passes on to the command. Other
+// platforms have no process groups or SIGWINCH, so only the portable
+// interrupt signal is handled.
+var forwardedSignals = []os.Signal{os.Interrupt}
+
+// setProcessGroup is a no-op on platforms without process groups.
+func setProcessGroup(execCmd *exec.Cmd) func() {
+	return func() {}
+}
+
+// followJobControl is a no-op on platforms without job control.
+func followJobControl(execCmd *exec.Cmd, done <-chan struct{}) {}
+
+// signalProcessGroup sends sig to the command. Without process groups, any
+// processes it started are left alone.
+func signalProcessGroup(execCmd *exec.Cmd, sig syscall.Signal) {
+	if execCmd.Process == nil {
+		return
+	}
+	if sig == syscall.SIGKILL {
+		execCmd.Process.Kill()
+		return
+	}
+	execCmd.Process.Signal(sig)
+}
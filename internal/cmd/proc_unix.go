@@ -0,0 +1,120 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"unsafe"
+)
+
+// forwardedSignals are the signals nf passes on to the command.
+var forwardedSignals = []os.Signal{
+	syscall.SIGINT,
+	syscall.SIGTERM,
+	syscall.SIGHUP,
+	syscall.SIGQUIT,
+	syscall.SIGWINCH,
+}
+
+// setProcessGroup configures the command to run in a new process group. If
+// nf is in the foreground of a terminal, the new group is made the
+// foreground group so that the command can still read from the terminal
+// and receives Ctrl-C directly, the same way a shell runs a job. The
+// returned function hands the terminal back to nf once the command is done.
+func setProcessGroup(execCmd *exec.Cmd) func() {
+	execCmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	tty := int(os.Stdin.Fd())
+	ownGroup := syscall.Getpgrp()
+	foreground, err := tcgetpgrp(tty)
+	if err != nil || foreground != ownGroup {
+		// Not a terminal, or nf is running in the background.
+		return func() {}
+	}
+
+	execCmd.SysProcAttr.Foreground = true
+	execCmd.SysProcAttr.Ctty = tty
+	return func() {
+		setForeground(tty, ownGroup)
+	}
+}
+
+// followJobControl keeps Ctrl-Z working while the command's process group
+// owns the terminal, until done is closed. Only the command receives
+// SIGTSTP, so when it stops, nf takes the terminal back and stops itself
+// with SIGTSTP to return control to the shell. When the shell continues nf
+// with fg, the command gets the terminal back and is continued too; after
+// bg, it is continued in the background.
+func followJobControl(execCmd *exec.Cmd, done <-chan struct{}) {
+	attr := execCmd.SysProcAttr
+	if attr == nil || !attr.Foreground {
+		return
+	}
+	tty, pid := attr.Ctty, execCmd.Process.Pid
+	ownGroup := syscall.Getpgrp()
+
+	children := make(chan os.Signal, 1)
+	signal.Notify(children, syscall.SIGCHLD)
+	defer signal.Stop(children)
+
+	for {
+		select {
+		case <-children:
+		case <-done:
+			return
+		}
+		if !childStopped(pid) {
+			continue
+		}
+
+		setForeground(tty, ownGroup)
+		syscall.Kill(syscall.Getpid(), syscall.SIGTSTP)
+
+		// nf only gets here once the shell has continued it.
+		if foreground, err := tcgetpgrp(tty); err == nil && foreground == ownGroup {
+			setForeground(tty, pid)
+		}
+		syscall.Kill(-pid, syscall.SIGCONT)
+	}
+}
+
+// setForeground makes pgrp the foreground process group of the terminal fd.
+func setForeground(tty, pgrp int) {
+	// A background process group gets SIGTTOU when it changes the
+	// foreground group, which would stop nf.
+	signal.Ignore(syscall.SIGTTOU)
+	defer signal.Reset(syscall.SIGTTOU)
+	tcsetpgrp(tty, pgrp)
+}
+
+// signalProcessGroup sends sig to every process in the command's process group.
+func signalProcessGroup(execCmd *exec.Cmd, sig syscall.Signal) {
+	if execCmd.Process == nil {
+		return
+	}
+	// A negative pid addresses the whole process group.
+	if err := syscall.Kill(-execCmd.Process.Pid, sig); err != nil {
+		execCmd.Process.Signal(sig)
+	}
+}
+
+// tcgetpgrp returns the foreground process group of the terminal fd.
+func tcgetpgrp(fd int) (int, error) {
+	var pgrp int32
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), uintptr(syscall.TIOCGPGRP), uintptr(unsafe.Pointer(&pgrp))); errno != 0 {
+		return 0, errno
+	}
+	return int(pgrp), nil
+}
+
+// tcsetpgrp makes pgrp the foreground process group of the terminal fd.
+func tcsetpgrp(fd int, pgrp int) error {
+	p := int32(pgrp)
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), uintptr(syscall.TIOCSPGRP), uintptr(unsafe.Pointer(&p))); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package cmd
+
+import (
+	"bytes"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunCommand_ForwardsTerminatingSignals(t *testing.T) {
+	// The command sends SIGTERM to its parent, which is nf (this test
+	// binary), and then waits to be stopped by the forwarded signal.
+	_, status, err := RunCommand([]string{"sh", "-c", "kill -TERM $PPID; sleep 5"}, RunOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, syscall.SIGTERM, status.Interrupt)
+	assert.Equal(t, syscall.SIGTERM, status.Signal)
+	assert.Equal(t, 143, status.Code)
+	assert.Equal(t, "was interrupted by SIGTERM", status.String())
+}
+
+func TestRunCommand_EscalatesToSIGKILL(t *testing.T) {
+	// The command ignores SIGTERM, so nf has to kill it after the grace period.
+	duration, status, err := RunCommand([]string{"sh", "-c", `trap "" TERM; kill -TERM $PPID; sleep 5`}, RunOptions{KillGrace: 100 * time.Millisecond})
+	require.NoError(t, err)
+
+	assert.Equal(t, syscall.SIGTERM, status.Interrupt)
+	assert.Equal(t, syscall.SIGKILL, status.Signal)
+	assert.Equal(t, 137, status.Code)
+	assert.Less(t, duration, 5*time.Second)
+}
+
+func TestRunCommand_Timeout(t *testing.T) {
+	duration, status, err := RunCommand([]string{"sleep", "5"}, RunOptions{
+		Timeout:       100 * time.Millisecond,
+		TimeoutSignal: syscall.SIGTERM,
+		TimeoutGrace:  time.Second,
+	})
+	require.NoError(t, err)
+
+	assert.True(t, status.TimedOut)
+	assert.Equal(t, 124, status.Code)
+	assert.Equal(t, syscall.SIGTERM, status.Signal)
+	assert.Equal(t, "timed out after 100ms", status.String())
+	assert.Less(t, duration, time.Second)
+}
+
+func TestRunCommand_TimeoutEscalatesToSIGKILL(t *testing.T) {
+	_, status, err := RunCommand([]string{"sh", "-c", `trap "" TERM; sleep 5`}, RunOptions{
+		Timeout:       100 * time.Millisecond,
+		TimeoutSignal: syscall.SIGTERM,
+		TimeoutGrace:  100 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	assert.True(t, status.TimedOut)
+	assert.Equal(t, syscall.SIGKILL, status.Signal)
+}
+
+func TestRunCommand_TimeoutWithoutGraceWaits(t *testing.T) {
+	// With no grace period, the command may take as long as it needs to
+	// clean up after the timeout signal.
+	var output bytes.Buffer
+	duration, status, err := RunCommand([]string{"sh", "-c", `trap "sleep 2; echo cleaned up" TERM; sleep 30 & wait`}, RunOptions{
+		Stdout:        &output,
+		Timeout:       100 * time.Millisecond,
+		TimeoutSignal: syscall.SIGTERM,
+	})
+	require.NoError(t, err)
+
+	assert.True(t, status.TimedOut)
+	assert.Equal(t, 124, status.Code)
+	assert.Equal(t, "cleaned up\n", output.String())
+	assert.GreaterOrEqual(t, duration, 2*time.Second)
+}
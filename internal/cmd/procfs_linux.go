@@ -0,0 +1,172 @@
+//go:build linux
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// userHZ is the unit of the start time in /proc/<pid>/stat. It is fixed at
+// 100 on every Linux architecture Go supports, and reading the real value
+// with sysconf would require cgo.
+const userHZ = 100
+
+// procInfo describes a process read from /proc.
+type procInfo struct {
+	PID  int
+	Name string
+	Args []string
+	Dir  string
+
+	// StartTime is when the process started, as reported by the kernel.
+	StartTime time.Time
+	// startTicks is the raw start time, used to notice that the PID has
+	// been reused by a different process.
+	startTicks uint64
+}
+
+// readProc reads the process with the given PID from /proc.
+func readProc(pid int) (procInfo, error) {
+	stat, err := readProcStat(pid)
+	if err != nil {
+		return procInfo{}, err
+	}
+	if stat.state == 'Z' {
+		return procInfo{}, fmt.Errorf("process %d has already exited", pid)
+	}
+	bootTime, err := readBootTime()
+	if err != nil {
+		return procInfo{}, err
+	}
+
+	info := procInfo{
+		PID:        pid,
+		Name:       stat.comm,
+		StartTime:  bootTime.Add(time.Duration(stat.startTicks) * time.Second / userHZ),
+		startTicks: stat.startTicks,
+	}
+	// The command line and directory are not readable for every process,
+	// e.g. kernel threads or those of other users; the name is enough then.
+	if cmdline, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid)); err == nil {
+		for _, arg := range bytes.Split(bytes.TrimRight(cmdline, "\x00"), []byte{0}) {
+			if len(arg) > 0 {
+				info.Args = append(info.Args, string(arg))
+			}
+		}
+	}
+	if len(info.Args) == 0 {
+		info.Args = []string{info.Name}
+	}
+	info.Dir, _ = os.Readlink(fmt.Sprintf("/proc/%d/cwd", pid))
+	return info, nil
+}
+
+// findProcsByName returns the processes whose name or executable matches
+// name, excluding nf itself.
+func findProcsByName(name string) ([]procInfo, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+
+	var procs []procInfo
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid == os.Getpid() {
+			continue
+		}
+		// Processes can exit while /proc is being read.
+		info, err := readProc(pid)
+		if err != nil {
+			continue
+		}
+		if info.Name == name || filepath.Base(info.Args[0]) == name {
+			procs = append(procs, info)
+		}
+	}
+	return procs, nil
+}
+
+// procAlive reports whether the process described by info is still
+// running. A zombie counts as exited, and so does a different process that
+// has since been given the same PID.
+func procAlive(info procInfo) bool {
+	stat, err := readProcStat(info.PID)
+	if err != nil {
+		return false
+	}
+	return stat.state != 'Z' && stat.startTicks == info.startTicks
+}
+
+// procStat holds the fields nf uses from /proc/<pid>/stat.
+type procStat struct {
+	comm       string
+	state      byte
+	startTicks uint64
+}
+
+// readProcStat parses /proc/<pid>/stat. The process name is enclosed in
+// parentheses and may itself contain spaces and parentheses, so the fields
+// after it are located from the last closing parenthesis.
+func readProcStat(pid int) (procStat, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return procStat{}, fmt.Errorf("no process with PID %d", pid)
+		}
+		return procStat{}, err
+	}
+
+	open := bytes.IndexByte(data, '(')
+	end := bytes.LastIndexByte(data, ')')
+	if open < 0 || end < open {
+		return procStat{}, fmt.Errorf("malformed /proc/%d/stat", pid)
+	}
+	// Fields from the third one on, starting with the state.
+	fields := strings.Fields(string(data[end+1:]))
+	const startTimeIndex = 22 - 3
+	if len(fields) <= startTimeIndex || len(fields[0]) != 1 {
+		return procStat{}, fmt.Errorf("malformed /proc/%d/stat", pid)
+	}
+	startTicks, err := strconv.ParseUint(fields[startTimeIndex], 10, 64)
+	if err != nil {
+		return procStat{}, fmt.Errorf("malformed /proc/%d/stat: %w", pid, err)
+	}
+
+	return procStat{
+		comm:       string(data[open+1 : end]),
+		state:      fields[0][0],
+		startTicks: startTicks,
+	}, nil
+}
+
+// readBootTime returns the system boot time from /proc/stat.
+func readBootTime() (time.Time, error) {
+	f, err := os.Open("/proc/stat")
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "btime "); ok {
+			seconds, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("malformed btime in /proc/stat: %w", err)
+			}
+			return time.Unix(seconds, 0), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return time.Time{}, err
+	}
+	return time.Time{}, fmt.Errorf("no btime in /proc/stat")
+}
@@ -0,0 +1,85 @@
+//go:build linux
+
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jules-labs/nf/internal/notifier"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadProc(t *testing.T) {
+	execCmd := exec.Command("sleep", "30")
+	execCmd.Dir = t.TempDir()
+	require.NoError(t, execCmd.Start())
+	defer execCmd.Process.Kill()
+
+	info, err := readProc(execCmd.Process.Pid)
+	require.NoError(t, err)
+	assert.Equal(t, "sleep", info.Name)
+	assert.Equal(t, []string{"sleep", "30"}, info.Args)
+	assert.Equal(t, execCmd.Dir, info.Dir)
+	// The start time has a resolution of 1/userHZ seconds, and the boot
+	// time one of a second.
+	assert.WithinDuration(t, time.Now(), info.StartTime, 2*time.Second)
+	assert.True(t, procAlive(info))
+
+	execCmd.Process.Kill()
+	execCmd.Wait()
+	assert.False(t, procAlive(info), "Expected the process to be gone after it was killed")
+
+	_, err = readProc(execCmd.Process.Pid)
+	assert.Error(t, err)
+}
+
+func TestProcAlive_Zombie(t *testing.T) {
+	execCmd := exec.Command("true")
+	require.NoError(t, execCmd.Start())
+	defer execCmd.Wait()
+
+	info, err := readProc(execCmd.Process.Pid)
+	if err != nil {
+		// The process already exited and is a zombie until it is waited for.
+		assert.ErrorContains(t, err, "already exited")
+		return
+	}
+	assert.Eventually(t, func() bool { return !procAlive(info) }, 5*time.Second, 10*time.Millisecond,
+		"Expected an exited but not yet reaped process to count as exited")
+}
+
+func TestFindWatchTarget(t *testing.T) {
+	_, err := findWatchTarget(0, "nf-test-no-such-process")
+	assert.ErrorContains(t, err, "no process named")
+
+	self, err := findWatchTarget(os.Getpid(), "")
+	require.NoError(t, err)
+	assert.Equal(t, os.Getpid(), self.PID)
+}
+
+func TestNewWatchEvent(t *testing.T) {
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	info := procInfo{PID: 42, Name: "make", Args: []string{"make", "-j8"}, Dir: "/src", StartTime: start}
+
+	event := newWatchEvent(info, start.Add(90*time.Second))
+	assert.Equal(t, notifier.OutcomeUnknown, event.Outcome)
+	assert.Equal(t, "make -j8", event.CommandLine())
+	assert.Equal(t, 90*time.Second, event.Duration)
+	assert.Equal(t, "/src", event.Dir)
+	assert.False(t, event.HasExitCode())
+	assert.Contains(t, event.Message(), "exit code is not available")
+}
+
+func TestWaitForExit_Signal(t *testing.T) {
+	self, err := readProc(os.Getpid())
+	require.NoError(t, err)
+
+	signals := make(chan os.Signal, 1)
+	signals <- syscall.SIGTERM
+	assert.Equal(t, syscall.SIGTERM, waitForExit(self, time.Millisecond, signals))
+}
@@ -0,0 +1,32 @@
+//go:build !linux
+
+package cmd
+
+import (
+	"errors"
+	"time"
+)
+
+// errNoProcfs is returned on platforms without a Linux-style /proc.
+var errNoProcfs = errors.New("watching processes is only supported on Linux")
+
+// procInfo describes a process being watched.
+type procInfo struct {
+	PID       int
+	Name      string
+	Args      []string
+	Dir       string
+	StartTime time.Time
+}
+
+func readProc(pid int) (procInfo, error) {
+	return procInfo{}, errNoProcfs
+}
+
+func findProcsByName(name string) ([]procInfo, error) {
+	return nil, errNoProcfs
+}
+
+func procAlive(info procInfo) bool {
+	return false
+}
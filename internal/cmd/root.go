@@ -1,9 +1,9 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"strings"
 	"time"
 
@@ -16,31 +16,9 @@ var (
 	cfgFile string
 	cfg     Config
 	// GetNotifier is a package-level variable so it can be replaced during tests.
-	GetNotifier = notifier.GetNotifier
+	GetNotifier = newNotifier
 )
 
-// runCommand is a package-level variable so it can be replaced during tests.
-var runCommand = func(args []string) (time.Duration, error) {
-	command := args[0]
-	commandArgs := args[1:]
-
-	// Prepare the command
-	execCmd := exec.Command(command, commandArgs...)
-	execCmd.Stdout = os.Stdout
-	execCmd.Stderr = os.Stderr
-	execCmd.Stdin = os.Stdin
-
-	fmt.Fprintf(os.Stderr, "nf: Running command: %s %s\n", command, strings.Join(commandArgs, " "))
-
-	startTime := time.Now()
-
-	// Run the command
-	err := execCmd.Run()
-
-	duration := time.Since(startTime)
-	return duration, err
-}
-
 // BuildRootCmd creates and returns the root command. This is used for testing.
 func BuildRootCmd() *cobra.Command {
 	rootCmd := &cobra.Command{
@@ -49,6 +27,16 @@ func BuildRootCmd() *cobra.Command {
 		Long: `nf (notify) runs a given command and sends a notification
 upon its completion, based on a time threshold.
 
+nf exits with the same exit code as the command it runs. If the command
+is killed by a signal, nf exits with 128 plus the signal number.
+
+SIGINT, SIGTERM, SIGHUP, SIGQUIT and SIGWINCH received by nf are forwarded
+to the command's process group, and the notification reports the run as
+interrupted.
+
+With --timeout, nf stops the command once the deadline has passed, sends a
+"timed out" notification and exits with code 124.
+
 Example: nf -t 60 -- long-running-build.sh`,
 		Args: cobra.ArbitraryArgs,
 		RunE: func(c *cobra.Command, args []string) error {
@@ -56,43 +44,99 @@ Example: nf -t 60 -- long-running-build.sh`,
 				return fmt.Errorf("a command to execute is required after --")
 			}
 
-			duration, err := runCommand(args)
+			stdout, stderr, tail, err := captureWriters(cfg)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "nf: Command finished with error: %v\n", err)
+				return err
 			}
 
-			fmt.Fprintf(os.Stderr, "nf: Execution took %s\n", duration.Round(time.Millisecond))
+			timeoutSignal, err := parseSignal(cfg.TimeoutSignal)
+			if err != nil {
+				return fmt.Errorf("invalid timeout signal: %w", err)
+			}
 
-			if int(duration.Seconds()) >= cfg.Threshold {
-				fmt.Fprintf(os.Stderr, "nf: Execution time (%.2fs) met or exceeded threshold (%ds). Preparing notification...\n", duration.Seconds(), cfg.Threshold)
+			trigger, err := newMatchTrigger(cfg)
+			if err != nil {
+				return err
+			}
+			stopMatches := func() {}
+			if trigger != nil {
+				stdout = combineWriters(stdout, trigger.writer())
+				stderr = combineWriters(stderr, trigger.writer())
+				stopMatches = trigger.start(args)
+			}
 
-				theNotifier, err := GetNotifier(cfg)
-				if err != nil {
-					return fmt.Errorf("failed to get notifier: %w", err)
-				}
+			stopSlackRun := startSlackRun(args, tail)
+			stopHeartbeats := startHeartbeats(args, heartbeatSchedule{
+				Interval:   cfg.HeartbeatInterval,
+				Milestones: cfg.HeartbeatAt,
+			}, tail)
+			duration, status, err := RunCommand(args, RunOptions{
+				Stdout:        stdout,
+				Stderr:        stderr,
+				KillGrace:     cfg.KillGrace,
+				Timeout:       cfg.Timeout,
+				TimeoutSignal: timeoutSignal,
+				TimeoutGrace:  cfg.TimeoutGrace,
+			})
+			stopSlackRun()
+			stopHeartbeats()
+			stopMatches()
+			if err != nil {
+				return fmt.Errorf("failed to run command: %w", err)
+			}
+			if !status.Success() {
+				fmt.Fprintf(os.Stderr, "nf: Command %s\n", status)
+			}
 
-				title := fmt.Sprintf("Command Finished: %s", args[0])
-				message := fmt.Sprintf("Command `%s` finished in %.2f seconds.", strings.Join(args, " "), duration.Seconds())
+			fmt.Fprintf(os.Stderr, "nf: Execution took %s\n", duration.Round(time.Millisecond))
 
-				err = theNotifier.Notify(title, message)
-				if err != nil {
-					return fmt.Errorf("failed to send notification: %w", err)
+			event := newEvent(args, duration, status)
+			if tail != nil {
+				event.Output = tail.String()
+			}
+			notifyErr := notifyIfOverThreshold(event)
+			finishSlackRun(event)
+
+			if !status.Success() {
+				// The command's own failure takes precedence so that nf exits
+				// with its exit code; a notification error is only reported.
+				if notifyErr != nil {
+					fmt.Fprintf(os.Stderr, "nf: %v\n", notifyErr)
 				}
-				fmt.Fprintln(os.Stderr, "nf: Notification sent successfully.")
-
-			} else {
-				fmt.Fprintf(os.Stderr, "nf: Execution time (%.2fs) did not exceed threshold (%ds). No notification will be sent.\n", duration.Seconds(), cfg.Threshold)
+				c.SilenceErrors = true
+				c.SilenceUsage = true
+				return &ExitCodeError{Status: status}
 			}
-			return nil
+			return notifyErr
 		},
 	}
 
 	// Define flags
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.config/nf/config.toml)")
 	rootCmd.PersistentFlags().IntP("threshold", "t", 10, "Threshold in seconds to trigger a notification")
+	rootCmd.PersistentFlags().StringSlice("notifier", nil, "Notifier to send to; repeat or comma-separate to use several (default is os)")
+	rootCmd.Flags().Duration("timeout", 0, "Stop the command if it runs for longer than this (e.g. 2h); nf then exits with code 124")
+	rootCmd.Flags().String("timeout-signal", "", "Signal sent to the command when it times out (default is TERM)")
+	rootCmd.Flags().Duration("timeout-grace", 0, "Kill the command with SIGKILL if it is still running this long after the timeout signal (default is 10s)")
+	rootCmd.Flags().StringArray("on-match", nil, "Send a notification as soon as the command prints a line matching this regular expression (repeatable)")
+	rootCmd.Flags().String("on-match-mode", "", "Whether each --on-match pattern notifies once or on every match: once or every (default is once)")
+	rootCmd.Flags().Duration("on-match-interval", 0, "Minimum time between --on-match notifications (e.g. 1m)")
+	rootCmd.Flags().Duration("heartbeat", 0, "Send a \"still running\" notification every time the command has run for this long (e.g. 1h)")
+	rootCmd.Flags().Duration("kill-grace", 0, "After forwarding SIGINT/SIGTERM/SIGHUP/SIGQUIT, kill the command with SIGKILL if it is still running after this long (e.g. 10s)")
+	rootCmd.Flags().String("capture", "", "Attach the tail of the command's output to the notification: none, stderr or combined (default is none)")
 
 	// Bind flags to viper
 	viper.BindPFlag("threshold", rootCmd.PersistentFlags().Lookup("threshold"))
+	viper.BindPFlag("notifier", rootCmd.PersistentFlags().Lookup("notifier"))
+	viper.BindPFlag("capture", rootCmd.Flags().Lookup("capture"))
+	viper.BindPFlag("kill_grace", rootCmd.Flags().Lookup("kill-grace"))
+	viper.BindPFlag("heartbeat_interval", rootCmd.Flags().Lookup("heartbeat"))
+	viper.BindPFlag("on_match", rootCmd.Flags().Lookup("on-match"))
+	viper.BindPFlag("on_match_mode", rootCmd.Flags().Lookup("on-match-mode"))
+	viper.BindPFlag("on_match_interval", rootCmd.Flags().Lookup("on-match-interval"))
+	viper.BindPFlag("timeout", rootCmd.Flags().Lookup("timeout"))
+	viper.BindPFlag("timeout_signal", rootCmd.Flags().Lookup("timeout-signal"))
+	viper.BindPFlag("timeout_grace", rootCmd.Flags().Lookup("timeout-grace"))
 
 	return rootCmd
 }
@@ -103,10 +147,28 @@ var rootCmd = BuildRootCmd()
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
+		var exitErr *ExitCodeError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Status.Code)
+		}
 		os.Exit(1)
 	}
 }
 
+// notifyIfOverThreshold sends a notification describing how the command
+// finished if its duration met or exceeded the configured threshold.
+func notifyIfOverThreshold(event notifier.Event) error {
+	duration := event.Duration
+	if int(duration.Seconds()) < cfg.Threshold {
+		fmt.Fprintf(os.Stderr, "nf: Execution time (%.2fs) did not exceed threshold (%ds). No notification will be sent.\n", duration.Seconds(), cfg.Threshold)
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "nf: Execution time (%.2fs) met or exceeded threshold (%ds). Preparing notification...\n", duration.Seconds(), cfg.Threshold)
+
+	return dispatch(event, nil)
+}
+
 func init() {
 	cobra.OnInitialize(initConfig)
 }
@@ -129,11 +191,23 @@ func initConfig() {
 
 	viper.SetDefault("threshold", 10)
 	viper.SetDefault("notifier", "os")
+	viper.SetDefault("notify_timeout", 30*time.Second)
+	viper.SetDefault("capture", captureNone)
+	viper.SetDefault("capture_lines", 20)
+	viper.SetDefault("capture_bytes", 4096)
+	viper.SetDefault("timeout_signal", "TERM")
+	viper.SetDefault("timeout_grace", 10*time.Second)
+	viper.SetDefault("on_match_mode", matchOnce)
+	viper.SetDefault("googlechat_thread", true)
+	viper.SetDefault("tmux_rename", true)
 
 	if err := viper.ReadInConfig(); err == nil {
 		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
 	}
 
+	// Unmarshal only sets the keys that are present, so values from an
+	// earlier call, such as rules, would otherwise survive.
+	cfg = Config{}
 	if err := viper.Unmarshal(&cfg); err != nil {
 		fmt.Fprintln(os.Stderr, "Error unmarshaling config:", err)
 		os.Exit(1)
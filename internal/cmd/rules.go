@@ -0,0 +1,266 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jules-labs/nf/internal/notifier"
+	"github.com/spf13/cobra"
+)
+
+// Rule routes matching events to specific notifiers. All conditions that
+// are set must match for the rule to fire; unset conditions match anything.
+type Rule struct {
+	// Name identifies the rule in `nf rules test` output.
+	Name string `mapstructure:"name"`
+
+	// Command is a glob matched against the full command line, where `*`
+	// matches any sequence of characters (including spaces and slashes).
+	Command string `mapstructure:"command"`
+
+	// CommandRegex is a regular expression matched against the full command line.
+	CommandRegex string `mapstructure:"command_regex"`
+
+	// Outcomes restricts the rule to these outcomes: "succeeded", "failed",
+	// "killed", "interrupted", "timed_out", "running" (heartbeats) or
+	// "matched" (on_match triggers).
+	Outcomes []string `mapstructure:"outcome"`
+
+	// ExitCodes restricts the rule to these exit codes.
+	ExitCodes []int `mapstructure:"exit_codes"`
+
+	// MinDuration and MaxDuration restrict the rule to a duration range.
+	// Either bound may be left unset.
+	MinDuration time.Duration `mapstructure:"min_duration"`
+	MaxDuration time.Duration `mapstructure:"max_duration"`
+
+	// Dir is a glob matched against the working directory.
+	Dir string `mapstructure:"dir"`
+
+	// Host is a glob matched against the hostname.
+	Host string `mapstructure:"host"`
+
+	// Notifiers are the notifiers a matching event is sent to. A matching
+	// rule with no notifiers suppresses the notification.
+	Notifiers []string `mapstructure:"notifiers"`
+
+	// Stop prevents later rules from being evaluated once this rule matches.
+	Stop bool `mapstructure:"stop"`
+}
+
+// Matches reports whether the event satisfies every condition of the rule.
+func (r Rule) Matches(event notifier.Event) (bool, error) {
+	commandLine := event.CommandLine()
+
+	if r.Command != "" && !globMatch(r.Command, commandLine) {
+		return false, nil
+	}
+	if r.CommandRegex != "" {
+		re, err := regexp.Compile(r.CommandRegex)
+		if err != nil {
+			return false, fmt.Errorf("invalid command_regex %q: %w", r.CommandRegex, err)
+		}
+		if !re.MatchString(commandLine) {
+			return false, nil
+		}
+	}
+	if len(r.Outcomes) > 0 {
+		matched := false
+		for _, outcome := range r.Outcomes {
+			o, err := parseOutcome(outcome)
+			if err != nil {
+				return false, err
+			}
+			matched = matched || o == event.Outcome
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	if len(r.ExitCodes) > 0 {
+		if !event.HasExitCode() || !containsInt(r.ExitCodes, event.ExitCode) {
+			return false, nil
+		}
+	}
+	if r.MinDuration > 0 && event.Duration < r.MinDuration {
+		return false, nil
+	}
+	if r.MaxDuration > 0 && event.Duration > r.MaxDuration {
+		return false, nil
+	}
+	if r.Dir != "" && !globMatch(r.Dir, event.Dir) {
+		return false, nil
+	}
+	if r.Host != "" && !globMatch(r.Host, event.Host) {
+		return false, nil
+	}
+	return true, nil
+}
+
+// displayName returns the rule's name, or its position if it has none.
+func (r Rule) displayName(index int) string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return fmt.Sprintf("rule #%d", index+1)
+}
+
+// parseOutcome parses an outcome name as used in the config file.
+func parseOutcome(s string) (notifier.Outcome, error) {
+	switch o := notifier.Outcome(strings.ToLower(strings.TrimSpace(s))); o {
+	case notifier.OutcomeSucceeded, notifier.OutcomeFailed, notifier.OutcomeKilled, notifier.OutcomeInterrupted, notifier.OutcomeTimedOut, notifier.OutcomeRunning, notifier.OutcomeMatched:
+		return o, nil
+	default:
+		return "", fmt.Errorf("invalid outcome %q: expected succeeded, failed, killed, interrupted, timed_out, running or matched", s)
+	}
+}
+
+// routeNotifiers returns the names of the notifiers the event should be
+// sent to, along with the indexes of the rules that matched. Every matching
+// rule contributes its notifiers; if no rule matches, the default notifiers
+// from the configuration are used.
+func routeNotifiers(config Config, event notifier.Event) ([]string, []int, error) {
+	var names []string
+	var matched []int
+
+	for i, rule := range config.Rules {
+		ok, err := rule.Matches(event)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", rule.displayName(i), err)
+		}
+		if !ok {
+			continue
+		}
+		matched = append(matched, i)
+		names = append(names, rule.Notifiers...)
+		if rule.Stop {
+			break
+		}
+	}
+
+	if len(matched) == 0 {
+		return config.Notifiers, nil, nil
+	}
+	return names, matched, nil
+}
+
+// globMatch reports whether s matches the glob pattern, where `*` matches
+// any sequence of characters and `?` matches any single character. Unlike
+// filepath.Match, `*` also matches path separators, so "terraform *" matches
+// "terraform apply -auto-approve" and "/home/*" matches nested directories.
+func globMatch(pattern, s string) bool {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String()).MatchString(s)
+}
+
+// joinNotifiers formats a list of notifier names for display.
+func joinNotifiers(names []string) string {
+	if len(names) == 0 {
+		return "(none)"
+	}
+	return strings.Join(names, ", ")
+}
+
+func containsInt(values []int, v int) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+func newRulesCmd() *cobra.Command {
+	rulesCmd := &cobra.Command{
+		Use:   "rules",
+		Short: "Inspects the notification routing rules.",
+	}
+	rulesCmd.AddCommand(newRulesTestCmd())
+	return rulesCmd
+}
+
+func newRulesTestCmd() *cobra.Command {
+	var exitCode int
+	var duration time.Duration
+
+	rulesTestCmd := &cobra.Command{
+		Use:   "test [flags] -- [command]",
+		Short: "Shows which routing rules would fire for a command, without running it.",
+		Long: `Evaluates the routing rules from the config file against a command
+as if it had finished with the given exit code and duration, and prints
+which rules match and which notifiers would be used. The command is not run.
+
+Example: nf rules test --exit-code 1 --duration 45m -- terraform apply`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			status := ExitStatus{Code: exitCode}
+			event := newEvent(args, duration, status)
+
+			out := c.OutOrStdout()
+			fmt.Fprintf(out, "Command:   %s\n", event.CommandLine())
+			fmt.Fprintf(out, "Outcome:   %s\n", event.StatusText())
+			fmt.Fprintf(out, "Duration:  %s\n", event.Duration)
+			fmt.Fprintf(out, "Directory: %s\n", event.Dir)
+			fmt.Fprintf(out, "Host:      %s\n\n", event.Host)
+
+			if len(cfg.Rules) == 0 {
+				fmt.Fprintln(out, "No rules are configured.")
+			}
+
+			names, matched, err := routeNotifiers(cfg, event)
+			if err != nil {
+				return fmt.Errorf("failed to evaluate rules: %w", err)
+			}
+
+			isMatched := make(map[int]bool)
+			for _, i := range matched {
+				isMatched[i] = true
+			}
+			stopped := false
+			for i, rule := range cfg.Rules {
+				switch {
+				case stopped:
+					fmt.Fprintf(out, "  skipped   %s\n", rule.displayName(i))
+				case isMatched[i]:
+					fmt.Fprintf(out, "  fires     %s -> %s\n", rule.displayName(i), joinNotifiers(rule.Notifiers))
+					stopped = rule.Stop
+				default:
+					fmt.Fprintf(out, "  no match  %s\n", rule.displayName(i))
+				}
+			}
+
+			if len(matched) == 0 {
+				fmt.Fprintf(out, "\nNo rule matched; the default notifiers would be used: %s\n", joinNotifiers(names))
+			} else {
+				fmt.Fprintf(out, "\nNotifiers: %s\n", joinNotifiers(names))
+			}
+			if int(duration.Seconds()) < cfg.Threshold {
+				fmt.Fprintf(out, "Note: the duration is below the threshold (%ds), so no notification would be sent.\n", cfg.Threshold)
+			}
+			return nil
+		},
+	}
+
+	rulesTestCmd.Flags().IntVar(&exitCode, "exit-code", 0, "Exit code to assume the command finished with")
+	rulesTestCmd.Flags().DurationVar(&duration, "duration", 0, "Duration to assume the command ran for (e.g. 45m)")
+
+	return rulesTestCmd
+}
+
+func init() {
+	rootCmd.AddCommand(newRulesCmd())
+}
@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jules-labs/nf/internal/notifier"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testRules = []Rule{
+	{
+		Name:      "terraform failures",
+		Command:   "terraform apply*",
+		Outcomes:  []string{"failed"},
+		Notifiers: []string{"teams"},
+	},
+	{
+		Name:        "long jobs",
+		MinDuration: 30 * time.Minute,
+		Notifiers:   []string{"app"},
+	},
+	{
+		Name:         "go test failures",
+		CommandRegex: `^go test\b`,
+		Outcomes:     []string{"failed", "killed"},
+		Notifiers:    []string{"os"},
+		Stop:         true,
+	},
+	{
+		Name:      "build host",
+		Host:      "ci-*",
+		Dir:       "/srv/*",
+		Notifiers: []string{"slack"},
+	},
+}
+
+func TestRouteNotifiers(t *testing.T) {
+	config := Config{Notifiers: []string{"os"}, Rules: testRules}
+
+	testCases := []struct {
+		name              string
+		event             notifier.Event
+		expectedNotifiers []string
+		expectedMatched   []int
+	}{
+		{
+			name:              "no rule matches",
+			event:             notifier.Event{Command: "make", Outcome: notifier.OutcomeSucceeded, Duration: time.Minute},
+			expectedNotifiers: []string{"os"},
+		},
+		{
+			name:              "failed terraform apply that ran long",
+			event:             notifier.Event{Command: "terraform", Args: []string{"apply", "-auto-approve"}, Outcome: notifier.OutcomeFailed, ExitCode: 1, Duration: 45 * time.Minute},
+			expectedNotifiers: []string{"teams", "app"},
+			expectedMatched:   []int{0, 1},
+		},
+		{
+			name:              "successful terraform apply",
+			event:             notifier.Event{Command: "terraform", Args: []string{"apply"}, Outcome: notifier.OutcomeSucceeded},
+			expectedNotifiers: []string{"os"},
+		},
+		{
+			name:              "stop prevents later rules",
+			event:             notifier.Event{Command: "go", Args: []string{"test", "./..."}, Outcome: notifier.OutcomeFailed, ExitCode: 1, Host: "ci-1", Dir: "/srv/nf"},
+			expectedNotifiers: []string{"os"},
+			expectedMatched:   []int{2},
+		},
+		{
+			name:              "host and directory globs",
+			event:             notifier.Event{Command: "make", Outcome: notifier.OutcomeSucceeded, Host: "ci-1", Dir: "/srv/nf/backend"},
+			expectedNotifiers: []string{"slack"},
+			expectedMatched:   []int{3},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			names, matched, err := routeNotifiers(config, tc.event)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedNotifiers, names)
+			assert.Equal(t, tc.expectedMatched, matched)
+		})
+	}
+}
+
+func TestRule_Matches(t *testing.T) {
+	event := notifier.Event{Command: "make", Outcome: notifier.OutcomeFailed, ExitCode: 2, Duration: 10 * time.Minute}
+
+	testCases := []struct {
+		name     string
+		rule     Rule
+		expected bool
+	}{
+		{name: "empty rule matches everything", rule: Rule{}, expected: true},
+		{name: "exit code matches", rule: Rule{ExitCodes: []int{1, 2}}, expected: true},
+		{name: "exit code does not match", rule: Rule{ExitCodes: []int{0}}, expected: false},
+		{name: "within duration range", rule: Rule{MinDuration: 5 * time.Minute, MaxDuration: 15 * time.Minute}, expected: true},
+		{name: "above maximum duration", rule: Rule{MaxDuration: 5 * time.Minute}, expected: false},
+		{name: "outcome does not match", rule: Rule{Outcomes: []string{"succeeded"}}, expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			matched, err := tc.rule.Matches(event)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, matched)
+		})
+	}
+
+	_, err := Rule{CommandRegex: "("}.Matches(event)
+	assert.ErrorContains(t, err, "invalid command_regex")
+
+	_, err = Rule{Outcomes: []string{"exploded"}}.Matches(event)
+	assert.ErrorContains(t, err, "invalid outcome")
+}
+
+func TestRulesTestCmd(t *testing.T) {
+	originalCfg, originalCfgFile := cfg, cfgFile
+	defer func() {
+		cfg, cfgFile = originalCfg, originalCfgFile
+		viper.Reset()
+	}()
+
+	// The command loads the configuration itself, so the rules have to
+	// come from a file.
+	cfgFile = filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(cfgFile, []byte(`
+notifier = "os"
+
+[[rules]]
+name = "terraform failures"
+command = "terraform apply*"
+outcome = ["failed"]
+notifiers = ["teams"]
+
+[[rules]]
+name = "long jobs"
+min_duration = "30m"
+notifiers = ["app"]
+
+[[rules]]
+name = "go test failures"
+command_regex = '^go test\b'
+outcome = ["failed", "killed"]
+notifiers = ["os"]
+stop = true
+`), 0o644))
+
+	out := &bytes.Buffer{}
+	rulesTestCmd := newRulesTestCmd()
+	rulesTestCmd.SetOut(out)
+	rulesTestCmd.SetArgs([]string{"--exit-code", "1", "--duration", "45m", "--", "terraform", "apply"})
+	require.NoError(t, rulesTestCmd.Execute())
+
+	assert.Contains(t, out.String(), "Outcome:   failed with exit code 1")
+	assert.Contains(t, out.String(), "fires     terraform failures -> teams")
+	assert.Contains(t, out.String(), "fires     long jobs -> app")
+	assert.Contains(t, out.String(), "no match  go test failures")
+	assert.Contains(t, out.String(), "Notifiers: teams, app")
+}
+
+func TestInitConfigDropsEarlierRules(t *testing.T) {
+	originalCfg, originalCfgFile := cfg, cfgFile
+	defer func() {
+		cfg, cfgFile = originalCfg, originalCfgFile
+		viper.Reset()
+	}()
+
+	dir := t.TempDir()
+	withRules := filepath.Join(dir, "rules.toml")
+	require.NoError(t, os.WriteFile(withRules, []byte(`
+[[rules]]
+name = "quiet sleeps"
+command = "sleep*"
+notifiers = []
+stop = true
+`), 0o644))
+	withoutRules := filepath.Join(dir, "plain.toml")
+	require.NoError(t, os.WriteFile(withoutRules, []byte(`notifier = ["os", "slack"]`), 0o644))
+
+	cfgFile = withRules
+	initConfig()
+	require.Len(t, cfg.Rules, 1)
+
+	// Running again, as the feature suite does for every scenario, must
+	// not keep the rules of the first configuration.
+	viper.Reset()
+	cfgFile = withoutRules
+	initConfig()
+	assert.Empty(t, cfg.Rules)
+	assert.Equal(t, []string{"os", "slack"}, cfg.Notifiers)
+}
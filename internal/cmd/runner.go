@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// RunOptions controls how RunCommand runs the wrapped command.
+type RunOptions struct {
+	// Stdout and Stderr, if set, receive a copy of the command's output.
+	// The output is always written to the terminal as well.
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// KillGrace is how long to wait after forwarding a terminating signal
+	// before killing the command with SIGKILL. Zero disables escalation.
+	KillGrace time.Duration
+
+	// Timeout stops the command once it has run for this long. Zero means
+	// no timeout.
+	Timeout time.Duration
+	// TimeoutSignal is sent to the command's process group when it times out.
+	TimeoutSignal syscall.Signal
+	// TimeoutGrace is how long to wait after TimeoutSignal before killing
+	// the command with SIGKILL. Zero disables escalation.
+	TimeoutGrace time.Duration
+}
+
+// RunCommand is a package-level variable so it can be replaced during tests.
+// The returned error is only non-nil if the command could not be run at all;
+// a command that runs and fails is reported through the ExitStatus.
+var RunCommand = func(args []string, opts RunOptions) (time.Duration, ExitStatus, error) {
+	command := args[0]
+	commandArgs := args[1:]
+
+	// Prepare the command
+	execCmd := exec.Command(command, commandArgs...)
+	execCmd.Stdout = teeWriter(os.Stdout, opts.Stdout)
+	execCmd.Stderr = teeWriter(os.Stderr, opts.Stderr)
+	execCmd.Stdin = os.Stdin
+	// When the output is copied through a pipe, a background process left
+	// behind by the command could keep the pipe open forever. Stop waiting
+	// for it shortly after the command itself has exited. The timeout is
+	// handled by forwardSignals instead of a context, since exec.Cmd would
+	// kill only the command itself once WaitDelay has passed after the
+	// context is done, regardless of the timeout grace period.
+	execCmd.WaitDelay = time.Second
+
+	// Run the command in its own process group so that signals can be
+	// forwarded to everything it starts.
+	restoreTerminal := setProcessGroup(execCmd)
+	defer restoreTerminal()
+
+	// Catch signals before starting the command, so none arrive in between
+	// and kill nf instead of being forwarded.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwardedSignals...)
+	defer signal.Stop(signals)
+
+	fmt.Fprintf(os.Stderr, "nf: Running command: %s %s\n", command, strings.Join(commandArgs, " "))
+
+	startTime := time.Now()
+
+	if err := execCmd.Start(); err != nil {
+		return 0, ExitStatus{}, err
+	}
+
+	var timedOut <-chan time.Time
+	if opts.Timeout > 0 {
+		timer := time.NewTimer(opts.Timeout)
+		defer timer.Stop()
+		timedOut = timer.C
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan runStop, 1)
+	go func() {
+		stopped <- forwardSignals(execCmd, signals, timedOut, opts, done)
+	}()
+	go followJobControl(execCmd, done)
+
+	err := execCmd.Wait()
+	close(done)
+
+	duration := time.Since(startTime)
+	status, err := exitStatusFromError(err)
+	stop := <-stopped
+	status.Interrupt = stop.Interrupt
+	if stop.TimedOut {
+		// Like timeout(1), report a timeout with exit code 124 regardless of
+		// how the command reacted to the signal.
+		status.TimedOut = true
+		status.Timeout = opts.Timeout
+		status.Code = timeoutExitCode
+	}
+	// Ctrl-C in a terminal goes straight to the command's process group
+	// rather than through nf, so a command killed by SIGINT was interrupted.
+	if status.Interrupt == 0 && status.Signal == syscall.SIGINT {
+		status.Interrupt = syscall.SIGINT
+	}
+	return duration, status, err
+}
+
+// timeoutExitCode is the exit code for a timed out command, the same as
+// used by timeout(1).
+const timeoutExitCode = 124
+
+// runStop describes why forwardSignals asked the command to stop, if it did.
+type runStop struct {
+	// Interrupt is the first terminating signal nf received, or zero.
+	Interrupt syscall.Signal
+	// TimedOut is set if the timeout signal was sent.
+	TimedOut bool
+}
+
+// forwardSignals forwards signals received by nf to the command's process
+// group until done is closed, and sends opts.TimeoutSignal to it once
+// timedOut fires. If a terminating signal is received and opts.KillGrace is
+// positive, the process group is killed once the grace period has passed;
+// likewise for opts.TimeoutGrace after a timeout. A grace period of zero
+// waits for the command indefinitely.
+func forwardSignals(execCmd *exec.Cmd, signals <-chan os.Signal, timedOut <-chan time.Time, opts RunOptions, done <-chan struct{}) runStop {
+	var stop runStop
+	var kill <-chan time.Time
+	var killReason string
+
+	for {
+		select {
+		case sig := <-signals:
+			s, ok := sig.(syscall.Signal)
+			if !ok {
+				continue
+			}
+			if stop.Interrupt == 0 && isTerminatingSignal(s) {
+				stop.Interrupt = s
+				fmt.Fprintf(os.Stderr, "nf: Received %s, forwarding to command\n", signalName(s))
+				if opts.KillGrace > 0 && kill == nil {
+					kill = time.After(opts.KillGrace)
+					killReason = fmt.Sprintf("%s after %s", opts.KillGrace, signalName(s))
+				}
+			}
+			signalProcessGroup(execCmd, s)
+		case <-timedOut:
+			timedOut = nil
+			stop.TimedOut = true
+			fmt.Fprintf(os.Stderr, "nf: Command timed out after %s, sending %s\n", opts.Timeout, signalName(opts.TimeoutSignal))
+			signalProcessGroup(execCmd, opts.TimeoutSignal)
+			if opts.TimeoutGrace > 0 && kill == nil {
+				kill = time.After(opts.TimeoutGrace)
+				killReason = fmt.Sprintf("%s after %s", opts.TimeoutGrace, signalName(opts.TimeoutSignal))
+			}
+		case <-kill:
+			kill = nil
+			fmt.Fprintf(os.Stderr, "nf: Command still running %s, killing it\n", killReason)
+			signalProcessGroup(execCmd, syscall.SIGKILL)
+		case <-done:
+			return stop
+		}
+	}
+}
+
+// isTerminatingSignal reports whether sig asks the command to stop, as
+// opposed to informational signals such as SIGWINCH.
+func isTerminatingSignal(sig syscall.Signal) bool {
+	switch sig {
+	case syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jules-labs/nf/internal/notifier"
+)
+
+// ExitStatus describes how the wrapped command terminated.
+type ExitStatus struct {
+	// Code is the exit code nf itself should exit with. For commands killed
+	// by a signal this follows the shell convention of 128+signal.
+	Code int
+
+	// Signal is the signal that killed the command, or zero if it exited normally.
+	Signal syscall.Signal
+
+	// Interrupt is the signal that interrupted the run, e.g. SIGTERM sent to
+	// nf by a CI runner or Ctrl-C in the terminal, or zero if the command
+	// was not interrupted.
+	Interrupt syscall.Signal
+
+	// TimedOut is set if the command was stopped because it ran for longer
+	// than Timeout. Code is then 124, as with timeout(1).
+	TimedOut bool
+	Timeout  time.Duration
+}
+
+// Success reports whether the command exited with code 0 within its timeout.
+func (s ExitStatus) Success() bool {
+	return s.Code == 0 && s.Signal == 0 && !s.TimedOut
+}
+
+// String describes the outcome in a form suitable for notifications,
+// e.g. "succeeded", "failed with exit code 2" or "was killed by SIGTERM".
+func (s ExitStatus) String() string {
+	switch {
+	case s.TimedOut:
+		return fmt.Sprintf("timed out after %s", s.Timeout)
+	case s.Interrupt != 0:
+		return fmt.Sprintf("was interrupted by %s", signalName(s.Interrupt))
+	case s.Signal != 0:
+		return fmt.Sprintf("was killed by %s", signalName(s.Signal))
+	case s.Code != 0:
+		return fmt.Sprintf("failed with exit code %d", s.Code)
+	default:
+		return "succeeded"
+	}
+}
+
+// exitStatusFromError converts the error returned by exec.Cmd.Wait into an
+// ExitStatus. Errors that are not caused by the process exiting are
+// returned unchanged, since they mean the command could not be run at all.
+func exitStatusFromError(err error) (ExitStatus, error) {
+	// ErrWaitDelay means the command succeeded but left a background process
+	// holding its output open; that is still a success.
+	if err == nil || errors.Is(err, exec.ErrWaitDelay) {
+		return ExitStatus{}, nil
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return ExitStatus{}, err
+	}
+
+	if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		return ExitStatus{Code: 128 + int(ws.Signal()), Signal: ws.Signal()}, nil
+	}
+	return ExitStatus{Code: exitErr.ExitCode()}, nil
+}
+
+// newEvent builds the notification event for a command that ran for
+// duration and finished with status.
+func newEvent(args []string, duration time.Duration, status ExitStatus) notifier.Event {
+	endTime := time.Now()
+	event := notifier.Event{
+		Command:   args[0],
+		Args:      args[1:],
+		ExitCode:  status.Code,
+		Duration:  duration,
+		StartTime: endTime.Add(-duration),
+		EndTime:   endTime,
+	}
+
+	switch {
+	case status.TimedOut:
+		event.Outcome = notifier.OutcomeTimedOut
+		event.Timeout = status.Timeout
+	case status.Interrupt != 0:
+		event.Outcome = notifier.OutcomeInterrupted
+		event.Signal = signalName(status.Interrupt)
+	case status.Signal != 0:
+		event.Outcome = notifier.OutcomeKilled
+		event.Signal = signalName(status.Signal)
+	case status.Code != 0:
+		event.Outcome = notifier.OutcomeFailed
+	default:
+		event.Outcome = notifier.OutcomeSucceeded
+	}
+
+	// Host and directory are best-effort; a notification without them is
+	// still useful.
+	event.Host, _ = os.Hostname()
+	event.Dir, _ = os.Getwd()
+	event.User = currentUser()
+	return event
+}
+
+// currentUser returns the name of the user nf runs as, or an empty string
+// if it cannot be determined.
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
+
+// ExitCodeError is returned by the root command when the wrapped command
+// did not succeed. Execute uses it to make nf exit with the same code.
+type ExitCodeError struct {
+	Status ExitStatus
+}
+
+func (e *ExitCodeError) Error() string {
+	return fmt.Sprintf("command %s", e.Status)
+}
+
+// signalNames maps the signals a command is commonly killed by to their
+// conventional names. syscall.Signal.String returns a description such as
+// "terminated" rather than "SIGTERM", which is less useful in a notification.
+var signalNames = map[syscall.Signal]string{
+	syscall.SIGHUP:  "SIGHUP",
+	syscall.SIGINT:  "SIGINT",
+	syscall.SIGQUIT: "SIGQUIT",
+	syscall.SIGILL:  "SIGILL",
+	syscall.SIGTRAP: "SIGTRAP",
+	syscall.SIGABRT: "SIGABRT",
+	syscall.SIGBUS:  "SIGBUS",
+	syscall.SIGFPE:  "SIGFPE",
+	syscall.SIGKILL: "SIGKILL",
+	syscall.SIGSEGV: "SIGSEGV",
+	syscall.SIGPIPE: "SIGPIPE",
+	syscall.SIGALRM: "SIGALRM",
+	syscall.SIGTERM: "SIGTERM",
+}
+
+// parseSignal parses a signal given as a name with or without the "SIG"
+// prefix, e.g. "TERM" or "SIGTERM", or as a number, e.g. "15".
+func parseSignal(s string) (syscall.Signal, error) {
+	if n, err := strconv.Atoi(s); err == nil && n > 0 {
+		return syscall.Signal(n), nil
+	}
+	name := strings.ToUpper(strings.TrimSpace(s))
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+	for sig, sigName := range signalNames {
+		if sigName == name {
+			return sig, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown signal %q", s)
+}
+
+// signalName returns the conventional name of sig, e.g. "SIGTERM".
+func signalName(sig syscall.Signal) string {
+	if name, ok := signalNames[sig]; ok {
+		return name
+	}
+	return fmt.Sprintf("signal %d", int(sig))
+}
@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSignal(t *testing.T) {
+	for _, s := range []string{"TERM", "SIGTERM", "sigterm", "15"} {
+		sig, err := parseSignal(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, syscall.SIGTERM, sig, s)
+	}
+
+	_, err := parseSignal("SIGNOPE")
+	assert.ErrorContains(t, err, `unknown signal "SIGNOPE"`)
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jules-labs/nf/internal/notifier"
+	"github.com/spf13/cobra"
+)
+
+func newWatchCmd() *cobra.Command {
+	var (
+		pid      int
+		name     string
+		interval time.Duration
+	)
+
+	watchCmd := &cobra.Command{
+		Use:   "watch (--pid PID | --name NAME)",
+		Short: "Waits for an already running process to exit and sends a notification.",
+		Long: `Waits for a process that was started without nf to exit, then sends a
+notification the same way nf does for commands it runs itself. The elapsed
+time is measured from when the process actually started, and the threshold
+applies as usual.
+
+The exit code of a process that nf did not start is not available, so the
+notification only reports that the process finished.
+
+Examples:
+  nf watch --pid 12345
+  nf watch --name make`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			if (pid == 0) == (name == "") {
+				return errors.New("exactly one of --pid or --name is required")
+			}
+			if interval <= 0 {
+				return fmt.Errorf("invalid poll interval %s", interval)
+			}
+
+			info, err := findWatchTarget(pid, name)
+			if err != nil {
+				return err
+			}
+
+			signals := make(chan os.Signal, 1)
+			signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+			defer signal.Stop(signals)
+
+			fmt.Fprintf(os.Stderr, "nf: Watching PID %d (%s), running for %s...\n",
+				info.PID, strings.Join(info.Args, " "), time.Since(info.StartTime).Round(time.Second))
+			if sig := waitForExit(info, interval, signals); sig != 0 {
+				fmt.Fprintf(os.Stderr, "nf: Received %s, stopped watching PID %d.\n", signalName(sig), info.PID)
+				// Like an interrupted command, exit with 128+signal so that
+				// scripts can tell this from the process having exited.
+				c.SilenceErrors = true
+				c.SilenceUsage = true
+				return &ExitCodeError{Status: ExitStatus{Code: 128 + int(sig), Interrupt: sig}}
+			}
+
+			event := newWatchEvent(info, time.Now())
+			fmt.Fprintf(os.Stderr, "nf: Process %d exited after %s\n", info.PID, event.Duration.Round(time.Millisecond))
+			return notifyIfOverThreshold(event)
+		},
+	}
+
+	watchCmd.Flags().IntVar(&pid, "pid", 0, "PID of the process to watch")
+	watchCmd.Flags().StringVar(&name, "name", "", "Name of the process to watch; it must match exactly one process")
+	watchCmd.Flags().DurationVar(&interval, "interval", time.Second, "How often to check whether the process has exited")
+
+	return watchCmd
+}
+
+func init() {
+	rootCmd.AddCommand(newWatchCmd())
+}
+
+// findWatchTarget looks up the process to watch by PID or by name. A name
+// matching several processes is an error, since nf cannot tell which one
+// was meant.
+func findWatchTarget(pid int, name string) (procInfo, error) {
+	if pid != 0 {
+		return readProc(pid)
+	}
+
+	procs, err := findProcsByName(name)
+	if err != nil {
+		return procInfo{}, err
+	}
+	switch len(procs) {
+	case 0:
+		return procInfo{}, fmt.Errorf("no process named %q", name)
+	case 1:
+		return procs[0], nil
+	default:
+		pids := make([]string, len(procs))
+		for i, p := range procs {
+			pids[i] = strconv.Itoa(p.PID)
+		}
+		return procInfo{}, fmt.Errorf("%d processes are named %q (PIDs %s); use --pid to pick one", len(procs), name, strings.Join(pids, ", "))
+	}
+}
+
+// waitForExit polls the process every interval until it has exited or a
+// signal is received on signals. A non-child cannot be waited for, so
+// polling is the only way to notice its exit. It returns the signal that
+// stopped the wait, or zero if the process exited.
+func waitForExit(info procInfo, interval time.Duration, signals <-chan os.Signal) syscall.Signal {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for procAlive(info) {
+		select {
+		case sig := <-signals:
+			if s, ok := sig.(syscall.Signal); ok {
+				return s
+			}
+			return syscall.SIGINT
+		case <-ticker.C:
+		}
+	}
+	return 0
+}
+
+// newWatchEvent builds the notification event for a watched process that
+// exited at endTime.
+func newWatchEvent(info procInfo, endTime time.Time) notifier.Event {
+	event := notifier.Event{
+		Command:   info.Args[0],
+		Args:      info.Args[1:],
+		Outcome:   notifier.OutcomeUnknown,
+		Duration:  endTime.Sub(info.StartTime),
+		StartTime: info.StartTime,
+		EndTime:   endTime,
+		Dir:       info.Dir,
+	}
+	event.Host, _ = os.Hostname()
+	event.User = currentUser()
+	return event
+}
@@ -1,10 +1,10 @@
 package notifier
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
+	"time"
 )
 
 // AppNotifier sends notifications to the custom backend API.
@@ -19,43 +19,94 @@ func NewAppNotifier(apiURL, apiToken string) *AppNotifier {
 }
 
 // appPayload is the JSON structure for the backend API request.
+// Title and message are always present; the remaining keys carry the
+// structured event and are omitted when sent through Notify.
 type appPayload struct {
 	Title   string `json:"title"`
 	Message string `json:"message"`
+
+	Command         string     `json:"command,omitempty"`
+	Args            []string   `json:"args,omitempty"`
+	Outcome         Outcome    `json:"outcome,omitempty"`
+	ExitCode        *int       `json:"exit_code,omitempty"`
+	Signal          string     `json:"signal,omitempty"`
+	Pattern         string     `json:"pattern,omitempty"`
+	DurationSeconds float64    `json:"duration_seconds,omitempty"`
+	TimeoutSeconds  float64    `json:"timeout_seconds,omitempty"`
+	Host            string     `json:"host,omitempty"`
+	User            string     `json:"user,omitempty"`
+	Dir             string     `json:"cwd,omitempty"`
+	StartTime       *time.Time `json:"started_at,omitempty"`
+	EndTime         *time.Time `json:"finished_at,omitempty"`
+	Output          string     `json:"output,omitempty"`
 }
 
+// appOutputLines and appOutputBytes limit the output in the payload, which
+// ends up in a mobile push notification with a small size limit.
+const (
+	appOutputLines = 10
+	appOutputBytes = 1024
+)
+
 // Notify sends a notification to the configured backend API.
 func (n *AppNotifier) Notify(title, message string) error {
 	payload := appPayload{
 		Title:   title,
 		Message: message,
 	}
+	return n.post(context.Background(), payload)
+}
 
-	payloadBytes, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("failed to marshal app payload: %w", err)
+// Send sends the event to the configured backend API as JSON.
+func (n *AppNotifier) Send(ctx context.Context, event Event) error {
+	payload := newAppPayload(event)
+	payload.Output = event.OutputTail(appOutputLines, appOutputBytes)
+	return n.post(ctx, payload)
+}
+
+// newAppPayload converts the event to the JSON structure of the backend
+// API, which the exec notifier also passes to its program.
+func newAppPayload(event Event) appPayload {
+	payload := appPayload{
+		Title:           event.Title(),
+		Message:         event.Message(),
+		Command:         event.Command,
+		Args:            event.Args,
+		Outcome:         event.Outcome,
+		Signal:          event.Signal,
+		Pattern:         event.Pattern,
+		DurationSeconds: event.Duration.Seconds(),
+		TimeoutSeconds:  event.Timeout.Seconds(),
+		Host:            event.Host,
+		User:            event.User,
+		Dir:             event.Dir,
+		Output:          event.Output,
+	}
+	if event.HasExitCode() {
+		exitCode := event.ExitCode
+		payload.ExitCode = &exitCode
 	}
+	if !event.StartTime.IsZero() {
+		payload.StartTime = &event.StartTime
+	}
+	if !event.EndTime.IsZero() {
+		payload.EndTime = &event.EndTime
+	}
+	return payload
+}
 
-	req, err := http.NewRequest("POST", n.APIURL, bytes.NewBuffer(payloadBytes))
+func (n *AppNotifier) post(ctx context.Context, payload appPayload) error {
+	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("failed to create http request: %w", err)
+		return fmt.Errorf("failed to marshal app payload: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	headers := map[string]string{}
 	if n.APIToken != "" {
-		req.Header.Set("Authorization", "Bearer "+n.APIToken)
+		headers["Authorization"] = "Bearer " + n.APIToken
 	}
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
+	if _, err := postJSON(ctx, n.APIURL, headers, payloadBytes); err != nil {
 		return fmt.Errorf("failed to send app notification: %w", err)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		return fmt.Errorf("failed to send app notification: received status code %d", resp.StatusCode)
-	}
-
 	return nil
 }
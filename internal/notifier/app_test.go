@@ -1,11 +1,13 @@
 package notifier
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -72,3 +74,45 @@ func TestAppNotifier_Notify(t *testing.T) {
 		})
 	}
 }
+
+func TestAppNotifier_Send(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	event := Event{
+		Command:   "make",
+		Args:      []string{"build"},
+		Outcome:   OutcomeFailed,
+		ExitCode:  2,
+		Duration:  90 * time.Second,
+		StartTime: start,
+		EndTime:   start.Add(90 * time.Second),
+		Host:      "buildbox",
+		Dir:       "/src/nf",
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bodyBytes, err := io.ReadAll(r.Body)
+		require.NoError(t, err, "Failed to read request body")
+		defer r.Body.Close()
+
+		var payload map[string]interface{}
+		require.NoError(t, json.Unmarshal(bodyBytes, &payload), "Failed to unmarshal request body")
+
+		assert.Equal(t, "Command Failed: make", payload["title"])
+		assert.Equal(t, "Command `make build` failed with exit code 2 after 90.00 seconds.", payload["message"])
+		assert.Equal(t, "make", payload["command"])
+		assert.Equal(t, []interface{}{"build"}, payload["args"])
+		assert.Equal(t, "failed", payload["outcome"])
+		assert.Equal(t, float64(2), payload["exit_code"])
+		assert.Equal(t, float64(90), payload["duration_seconds"])
+		assert.Equal(t, "buildbox", payload["host"])
+		assert.Equal(t, "/src/nf", payload["cwd"])
+		assert.Equal(t, "2024-05-01T12:00:00Z", payload["started_at"])
+		assert.Equal(t, "2024-05-01T12:01:30Z", payload["finished_at"])
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	err := NewAppNotifier(server.URL, "").Send(context.Background(), event)
+	assert.NoError(t, err, "Send returned an unexpected error")
+}
@@ -0,0 +1,177 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DiscordNotifier sends notifications to a Discord webhook.
+type DiscordNotifier struct {
+	WebhookURL string
+	// Username and AvatarURL override the webhook's default name and
+	// picture if set.
+	Username  string
+	AvatarURL string
+}
+
+// NewDiscordNotifier creates a new instance of DiscordNotifier.
+func NewDiscordNotifier(webhookURL, username, avatarURL string) *DiscordNotifier {
+	return &DiscordNotifier{WebhookURL: webhookURL, Username: username, AvatarURL: avatarURL}
+}
+
+// discordPayload is the JSON structure for a Discord webhook message.
+type discordPayload struct {
+	Username  string         `json:"username,omitempty"`
+	AvatarURL string         `json:"avatar_url,omitempty"`
+	Embeds    []discordEmbed `json:"embeds"`
+}
+
+// discordEmbed is a rich embed with a colored bar on its left.
+type discordEmbed struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	Color       int            `json:"color,omitempty"`
+	Fields      []discordField `json:"fields,omitempty"`
+	Timestamp   string         `json:"timestamp,omitempty"`
+}
+
+// discordField is a single name/value pair within an embed.
+type discordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+// Limits Discord places on embeds, and how much of the captured output is
+// included in the description.
+const (
+	discordFieldValueLimit = 1024
+	discordOutputBytes     = 3000
+)
+
+// Notify sends a message to the configured Discord webhook.
+func (d *DiscordNotifier) Notify(title, message string) error {
+	return d.post(context.Background(), d.newPayload(discordEmbed{Title: title, Description: message}))
+}
+
+// Send sends the event to the configured Discord webhook as an embed, with
+// the event metadata rendered as fields and any captured output as a code
+// block.
+func (d *DiscordNotifier) Send(ctx context.Context, event Event) error {
+	embed := discordEmbed{
+		Title:       event.Title(),
+		Description: event.Message(),
+		Color:       discordColor(event),
+	}
+	if tail := event.OutputTail(0, discordOutputBytes); tail != "" {
+		embed.Description += "\n" + slackCodeBlock(tail)
+	}
+	if !event.EndTime.IsZero() {
+		embed.Timestamp = event.EndTime.Format(time.RFC3339)
+	}
+	for _, f := range event.Fields() {
+		value := f.Value
+		if len(value) > discordFieldValueLimit {
+			value = truncateUTF8(value, discordFieldValueLimit-3) + "..."
+		}
+		embed.Fields = append(embed.Fields, discordField{
+			Name:  f.Name,
+			Value: value,
+			// The command line is usually too long to share a row.
+			Inline: f.Name != "Command",
+		})
+	}
+	return d.post(ctx, d.newPayload(embed))
+}
+
+func (d *DiscordNotifier) newPayload(embed discordEmbed) discordPayload {
+	return discordPayload{
+		Username:  d.Username,
+		AvatarURL: d.AvatarURL,
+		Embeds:    []discordEmbed{embed},
+	}
+}
+
+// discordColor returns the embed color for the event's outcome.
+func discordColor(event Event) int {
+	switch event.Outcome {
+	case OutcomeSucceeded:
+		return 0x2EB67D
+	case OutcomeFailed, OutcomeKilled, OutcomeTimedOut:
+		return 0xE01E5A
+	case OutcomeInterrupted:
+		return 0xECB22E
+	default:
+		return 0
+	}
+}
+
+// discordMaxAttempts is how often a rate-limited message is tried in total,
+// and discordMaxRetryAfter the longest rate-limit wait nf accepts. A
+// notification that would arrive minutes late is not worth blocking on.
+const (
+	discordMaxAttempts   = 3
+	discordMaxRetryAfter = 30 * time.Second
+)
+
+func (d *DiscordNotifier) post(ctx context.Context, payload discordPayload) error {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal discord payload: %w", err)
+	}
+
+	for attempt := 1; ; attempt++ {
+		retryAfter, err := d.postOnce(ctx, payloadBytes)
+		if err != nil || retryAfter == 0 {
+			return err
+		}
+		if attempt == discordMaxAttempts || retryAfter > discordMaxRetryAfter {
+			return fmt.Errorf("failed to send discord notification: rate limited, retry after %s", retryAfter)
+		}
+
+		timer := time.NewTimer(retryAfter)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("failed to send discord notification: %w", ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// postOnce posts the payload. If Discord rate-limits the request, it
+// returns how long to wait before trying again.
+func (d *DiscordNotifier) postOnce(ctx context.Context, payloadBytes []byte) (time.Duration, error) {
+	_, err := postJSON(ctx, d.WebhookURL, nil, payloadBytes)
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusTooManyRequests {
+		return discordRetryAfter(statusErr), nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to send discord notification: %w", err)
+	}
+	return 0, nil
+}
+
+// discordRetryAfter reads how long to wait from a 429 response. Discord
+// puts the number of seconds in the JSON body as retry_after, and also in
+// the Retry-After header. It never returns less than a millisecond, so the
+// caller can tell a rate limit from success.
+func discordRetryAfter(resp *httpStatusError) time.Duration {
+	var body struct {
+		RetryAfter float64 `json:"retry_after"`
+	}
+	seconds := 1.0
+	if err := json.Unmarshal(resp.Body, &body); err == nil && body.RetryAfter > 0 {
+		seconds = body.RetryAfter
+	} else if header, err := strconv.ParseFloat(strings.TrimSpace(resp.Header.Get("Retry-After")), 64); err == nil && header > 0 {
+		seconds = header
+	}
+	return max(time.Duration(seconds*float64(time.Second)), time.Millisecond)
+}
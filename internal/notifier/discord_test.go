@@ -0,0 +1,136 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscordNotifier_Send(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	event := Event{
+		Command:   "make",
+		Args:      []string{"build"},
+		Outcome:   OutcomeFailed,
+		ExitCode:  2,
+		Duration:  90 * time.Second,
+		StartTime: start,
+		EndTime:   start.Add(90 * time.Second),
+		Host:      "buildbox",
+		Output:    "error: missing semicolon",
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method, "Expected POST request")
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		bodyBytes, err := io.ReadAll(r.Body)
+		require.NoError(t, err, "Failed to read request body")
+		defer r.Body.Close()
+
+		var payload discordPayload
+		require.NoError(t, json.Unmarshal(bodyBytes, &payload), "Failed to unmarshal request body")
+
+		assert.Equal(t, "nf", payload.Username)
+		assert.Equal(t, "https://example.com/nf.png", payload.AvatarURL)
+		require.Len(t, payload.Embeds, 1)
+		embed := payload.Embeds[0]
+		assert.Equal(t, "Command Failed: make", embed.Title)
+		assert.Equal(t, "Command `make build` failed with exit code 2 after 90.00 seconds.\n```\nerror: missing semicolon\n```", embed.Description)
+		assert.Equal(t, 0xE01E5A, embed.Color)
+		assert.Equal(t, "2024-05-01T12:01:30Z", embed.Timestamp)
+		assert.Contains(t, embed.Fields, discordField{Name: "Command", Value: "make build", Inline: false})
+		assert.Contains(t, embed.Fields, discordField{Name: "Exit Code", Value: "2", Inline: true})
+		assert.Contains(t, embed.Fields, discordField{Name: "Duration", Value: "1m30s", Inline: true})
+		assert.Contains(t, embed.Fields, discordField{Name: "Host", Value: "buildbox", Inline: true})
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	err := NewDiscordNotifier(server.URL, "nf", "https://example.com/nf.png").Send(context.Background(), event)
+	assert.NoError(t, err, "Send returned an unexpected error")
+}
+
+func TestDiscordNotifier_RateLimit(t *testing.T) {
+	testCases := []struct {
+		name          string
+		retryBody     string
+		retryHeader   string
+		rateLimited   int32
+		expectedCalls int32
+		expectErr     bool
+	}{
+		{name: "retry after body", retryBody: `{"message": "You are being rate limited.", "retry_after": 0.01, "global": false}`, rateLimited: 1, expectedCalls: 2},
+		{name: "retry after header", retryHeader: "0.01", rateLimited: 1, expectedCalls: 2},
+		{name: "still rate limited", retryBody: `{"retry_after": 0.01}`, rateLimited: 10, expectedCalls: discordMaxAttempts, expectErr: true},
+		{name: "wait too long", retryBody: `{"retry_after": 600}`, rateLimited: 1, expectedCalls: 1, expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) <= tc.rateLimited {
+					if tc.retryHeader != "" {
+						w.Header().Set("Retry-After", tc.retryHeader)
+					}
+					w.WriteHeader(http.StatusTooManyRequests)
+					io.WriteString(w, tc.retryBody)
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer server.Close()
+
+			err := NewDiscordNotifier(server.URL, "", "").Notify("Test Title", "Test Message")
+			if tc.expectErr {
+				assert.ErrorContains(t, err, "rate limited")
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expectedCalls, calls.Load())
+		})
+	}
+}
+
+func TestDiscordNotifier_LongFieldValue(t *testing.T) {
+	// A two-byte rune straddles the limit, and must not be split.
+	command := strings.Repeat("a", discordFieldValueLimit-4) + strings.Repeat("é", 10)
+
+	var payload discordPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	err := NewDiscordNotifier(server.URL, "", "").Send(context.Background(), Event{Command: command, Outcome: OutcomeSucceeded})
+	require.NoError(t, err)
+
+	require.Len(t, payload.Embeds, 1)
+	value := payload.Embeds[0].Fields[0].Value
+	assert.True(t, utf8.ValidString(value), "Expected valid UTF-8")
+	assert.LessOrEqual(t, len(value), discordFieldValueLimit)
+	assert.Equal(t, strings.Repeat("a", discordFieldValueLimit-4)+"...", value)
+}
+
+func TestDiscordNotifier_ErrorHidesWebhookURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	webhookURL := server.URL + "/api/webhooks/123/secret-token"
+	server.Close()
+
+	err := NewDiscordNotifier(webhookURL, "", "").Send(context.Background(), Event{Command: "make"})
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret-token")
+}
@@ -0,0 +1,373 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Connection security modes for EmailNotifier.
+const (
+	// EmailSecurityNone sends everything, including credentials, in clear text.
+	EmailSecurityNone = "none"
+	// EmailSecuritySTARTTLS upgrades the connection with STARTTLS and fails
+	// if the server does not support it.
+	EmailSecuritySTARTTLS = "starttls"
+	// EmailSecurityTLS connects with TLS from the start, usually on port 465.
+	EmailSecurityTLS = "tls"
+)
+
+// SMTP authentication mechanisms for EmailNotifier.
+const (
+	EmailAuthPlain   = "plain"
+	EmailAuthLogin   = "login"
+	EmailAuthCRAMMD5 = "cram-md5"
+)
+
+// emailTimeout limits the whole SMTP conversation if the context has no
+// deadline of its own.
+const emailTimeout = 30 * time.Second
+
+// EmailNotifier sends notifications by email over SMTP.
+type EmailNotifier struct {
+	Host string
+	Port int
+	// Security is EmailSecurityNone, EmailSecuritySTARTTLS or EmailSecurityTLS.
+	Security string
+
+	// Username and Password authenticate with the server using Auth, one
+	// of EmailAuthPlain, EmailAuthLogin or EmailAuthCRAMMD5. No
+	// authentication is done if Username is empty.
+	Username string
+	Password string
+	Auth     string
+
+	From string
+	To   []string
+
+	// TLSConfig is used for TLS connections. If nil, the server certificate
+	// is verified against the system roots and Host.
+	TLSConfig *tls.Config
+}
+
+// NewEmailNotifier creates a new instance of EmailNotifier that connects
+// with STARTTLS and authenticates with PLAIN if a username is set.
+func NewEmailNotifier(host string, port int, from string, to []string) *EmailNotifier {
+	return &EmailNotifier{
+		Host:     host,
+		Port:     port,
+		Security: EmailSecuritySTARTTLS,
+		Auth:     EmailAuthPlain,
+		From:     from,
+		To:       to,
+	}
+}
+
+// Notify sends an email with the title as its subject.
+func (e *EmailNotifier) Notify(title, message string) error {
+	msg, err := e.buildMessage(title, message, "<p>"+html.EscapeString(message)+"</p>", "")
+	if err != nil {
+		return err
+	}
+	return e.send(context.Background(), msg)
+}
+
+// Send sends the event as an email with a plain text and an HTML body
+// listing the event metadata, and any captured output attached as a file.
+func (e *EmailNotifier) Send(ctx context.Context, event Event) error {
+	var text, htmlBody strings.Builder
+	text.WriteString(event.Message() + "\n\n")
+	htmlBody.WriteString("<p>" + html.EscapeString(event.Message()) + "</p>\n<table>\n")
+	for _, f := range event.Fields() {
+		fmt.Fprintf(&text, "%s: %s\n", f.Name, f.Value)
+		value := html.EscapeString(f.Value)
+		if f.Name == "Command" {
+			value = "<code>" + value + "</code>"
+		}
+		fmt.Fprintf(&htmlBody, "<tr><th align=\"left\">%s</th><td>%s</td></tr>\n", html.EscapeString(f.Name), value)
+	}
+	htmlBody.WriteString("</table>\n")
+	if event.Output != "" {
+		text.WriteString("\nThe captured output is attached.\n")
+		htmlBody.WriteString("<p>The captured output is attached.</p>\n")
+	}
+
+	msg, err := e.buildMessage(event.Title(), text.String(), htmlBody.String(), event.Output)
+	if err != nil {
+		return err
+	}
+	return e.send(ctx, msg)
+}
+
+// buildMessage renders a MIME message with alternative text and HTML
+// bodies, and the output as an attachment if it is not empty.
+func (e *EmailNotifier) buildMessage(subject, text, htmlBody, output string) ([]byte, error) {
+	var altBuf bytes.Buffer
+	alternative := multipart.NewWriter(&altBuf)
+	if err := writeQuotedPrintablePart(alternative, "text/plain; charset=utf-8", text); err != nil {
+		return nil, err
+	}
+	if err := writeQuotedPrintablePart(alternative, "text/html; charset=utf-8", htmlBody); err != nil {
+		return nil, err
+	}
+	if err := alternative.Close(); err != nil {
+		return nil, err
+	}
+
+	// The alternative bodies form the first part of the mixed message.
+	var body bytes.Buffer
+	mixed := multipart.NewWriter(&body)
+	part, err := mixed.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"multipart/alternative; boundary=" + strconv.Quote(alternative.Boundary())},
+	})
+	if err != nil {
+		return nil, err
+	}
+	part.Write(altBuf.Bytes())
+	if output != "" {
+		part, err := mixed.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {"text/plain; charset=utf-8"},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {`attachment; filename="output.log"`},
+		})
+		if err != nil {
+			return nil, err
+		}
+		writeBase64Lines(part, []byte(output))
+	}
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+
+	headers := []string{
+		"From: " + e.From,
+		"To: " + strings.Join(e.To, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"Message-ID: " + newMessageID(e.From),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/mixed; boundary=" + strconv.Quote(mixed.Boundary()),
+	}
+	return append([]byte(strings.Join(headers, "\r\n")+"\r\n\r\n"), body.Bytes()...), nil
+}
+
+// writeQuotedPrintablePart writes body as a quoted-printable part.
+func writeQuotedPrintablePart(w *multipart.Writer, contentType, body string) error {
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+	qp := quotedprintable.NewWriter(part)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// writeBase64Lines writes data base64-encoded in lines of 76 characters,
+// as MIME requires.
+func writeBase64Lines(w io.Writer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		w.Write([]byte(encoded[:76] + "\r\n"))
+		encoded = encoded[76:]
+	}
+	w.Write([]byte(encoded + "\r\n"))
+}
+
+// newMessageID returns a unique Message-ID in the domain of the sender.
+func newMessageID(from string) string {
+	domain := "localhost"
+	if addr, err := mail.ParseAddress(from); err == nil {
+		if i := strings.LastIndexByte(addr.Address, '@'); i >= 0 {
+			domain = addr.Address[i+1:]
+		}
+	}
+	return fmt.Sprintf("<nf.%d.%d@%s>", time.Now().UnixNano(), os.Getpid(), domain)
+}
+
+// send delivers the message to every recipient in one SMTP transaction.
+func (e *EmailNotifier) send(ctx context.Context, msg []byte) error {
+	from, err := mail.ParseAddress(e.From)
+	if err != nil {
+		return fmt.Errorf("invalid email sender %q: %w", e.From, err)
+	}
+	var recipients []string
+	for _, to := range e.To {
+		addrs, err := mail.ParseAddressList(to)
+		if err != nil {
+			return fmt.Errorf("invalid email recipient %q: %w", to, err)
+		}
+		for _, addr := range addrs {
+			recipients = append(recipients, addr.Address)
+		}
+	}
+	if len(recipients) == 0 {
+		return errors.New("failed to send email notification: no recipients")
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, emailTimeout)
+		defer cancel()
+	}
+
+	client, err := e.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to send email notification: %w", err)
+	}
+	defer client.Close()
+
+	if err := e.deliver(client, from.Address, recipients, msg); err != nil {
+		return fmt.Errorf("failed to send email notification: %w", err)
+	}
+	return nil
+}
+
+// dial connects to the server, secures the connection as configured and
+// returns the SMTP client.
+func (e *EmailNotifier) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(e.Host, strconv.Itoa(e.Port))
+	tlsConfig := e.TLSConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{ServerName: e.Host}
+	}
+
+	var conn net.Conn
+	var err error
+	switch e.Security {
+	case EmailSecurityTLS:
+		conn, err = (&tls.Dialer{Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	case EmailSecuritySTARTTLS, EmailSecurityNone:
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	default:
+		return nil, fmt.Errorf("unknown security mode %q", e.Security)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// The SMTP client has no context support, so the deadline is applied
+	// to the connection instead.
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, e.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if e.Security == EmailSecuritySTARTTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, errors.New("server does not support STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+	return client, nil
+}
+
+// deliver authenticates if configured and sends the message.
+func (e *EmailNotifier) deliver(client *smtp.Client, from string, recipients []string, msg []byte) error {
+	if e.Username != "" {
+		auth, err := e.smtpAuth()
+		if err != nil {
+			return err
+		}
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range recipients {
+		if err := client.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// smtpAuth returns the configured authentication mechanism.
+func (e *EmailNotifier) smtpAuth() (smtp.Auth, error) {
+	switch strings.ToLower(e.Auth) {
+	case EmailAuthPlain, "":
+		return smtp.PlainAuth("", e.Username, e.Password, e.Host), nil
+	case EmailAuthLogin:
+		return &loginAuth{username: e.Username, password: e.Password, host: e.Host}, nil
+	case EmailAuthCRAMMD5:
+		return smtp.CRAMMD5Auth(e.Username, e.Password), nil
+	default:
+		return nil, fmt.Errorf("unknown authentication mechanism %q", e.Auth)
+	}
+}
+
+// loginAuth implements the LOGIN mechanism, which net/smtp does not
+// provide but many servers still require.
+type loginAuth struct {
+	username, password, host string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	// Like smtp.PlainAuth, refuse to send the password in clear text other
+	// than to the local machine.
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch prompt := strings.ToLower(strings.TrimSpace(string(fromServer))); prompt {
+	case "username:":
+		return []byte(a.username), nil
+	case "password:":
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected LOGIN prompt %q", fromServer)
+	}
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
+}
@@ -0,0 +1,334 @@
+package notifier
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smtpStandIn is a minimal in-process SMTP server that records the last
+// message it received.
+type smtpStandIn struct {
+	listener    net.Listener
+	tlsConfig   *tls.Config
+	implicitTLS bool
+	password    string
+
+	mu       sync.Mutex
+	authUser string
+	authMech string
+	sawTLS   bool
+	from     string
+	rcpts    []string
+	data     string
+}
+
+func newSMTPStandIn(t *testing.T, tlsConfig *tls.Config, implicitTLS bool) *smtpStandIn {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	if implicitTLS {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+	s := &smtpStandIn{listener: listener, tlsConfig: tlsConfig, implicitTLS: implicitTLS, password: "secret"}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpStandIn) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpStandIn) serve(conn net.Conn) {
+	defer func() { conn.Close() }()
+	_, isTLS := conn.(*tls.Conn)
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 localhost ESMTP stand-in")
+
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250-localhost")
+			if s.tlsConfig != nil && !isTLS {
+				tp.PrintfLine("250-STARTTLS")
+			}
+			tp.PrintfLine("250 AUTH PLAIN LOGIN CRAM-MD5")
+		case "STARTTLS":
+			tp.PrintfLine("220 Ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if tlsConn.Handshake() != nil {
+				return
+			}
+			conn, isTLS = tlsConn, true
+			tp = textproto.NewConn(conn)
+		case "AUTH":
+			mech, initial, _ := strings.Cut(arg, " ")
+			user, ok := s.authenticate(tp, strings.ToUpper(mech), initial)
+			if !ok {
+				tp.PrintfLine("535 Authentication failed")
+				continue
+			}
+			s.mu.Lock()
+			s.authUser, s.authMech = user, strings.ToUpper(mech)
+			s.mu.Unlock()
+			tp.PrintfLine("235 Authentication succeeded")
+		case "MAIL":
+			s.mu.Lock()
+			s.from, s.rcpts, s.sawTLS = strings.TrimPrefix(arg, "FROM:"), nil, isTLS
+			s.mu.Unlock()
+			tp.PrintfLine("250 OK")
+		case "RCPT":
+			s.mu.Lock()
+			s.rcpts = append(s.rcpts, strings.TrimPrefix(arg, "TO:"))
+			s.mu.Unlock()
+			tp.PrintfLine("250 OK")
+		case "DATA":
+			tp.PrintfLine("354 Go ahead")
+			data, err := io.ReadAll(tp.DotReader())
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.data = string(data)
+			s.mu.Unlock()
+			tp.PrintfLine("250 OK")
+		case "QUIT":
+			tp.PrintfLine("221 Bye")
+			return
+		default:
+			tp.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+// authenticate runs the exchange for an AUTH command and returns the
+// authenticated user.
+func (s *smtpStandIn) authenticate(tp *textproto.Conn, mech, initial string) (string, bool) {
+	readResponse := func(challenge string) string {
+		tp.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte(challenge)))
+		line, _ := tp.ReadLine()
+		decoded, _ := base64.StdEncoding.DecodeString(line)
+		return string(decoded)
+	}
+
+	switch mech {
+	case "PLAIN":
+		decoded, _ := base64.StdEncoding.DecodeString(initial)
+		parts := strings.Split(string(decoded), "\x00")
+		return parts[1], len(parts) == 3 && parts[2] == s.password
+	case "LOGIN":
+		user := readResponse("Username:")
+		password := readResponse("Password:")
+		return user, password == s.password
+	case "CRAM-MD5":
+		challenge := "<1234.5678@localhost>"
+		user, digest, _ := strings.Cut(readResponse(challenge), " ")
+		mac := hmac.New(md5.New, []byte(s.password))
+		mac.Write([]byte(challenge))
+		return user, digest == hex.EncodeToString(mac.Sum(nil))
+	default:
+		return "", false
+	}
+}
+
+// message returns the last received message.
+func (s *smtpStandIn) message(t *testing.T) *mail.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	msg, err := mail.ReadMessage(strings.NewReader(s.data))
+	require.NoError(t, err)
+	return msg
+}
+
+// newTestTLSConfigs returns a server configuration with a self-signed
+// certificate for 127.0.0.1, and a client configuration trusting it.
+func newTestTLSConfigs(t *testing.T) (server, client *tls.Config) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "nf test"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	server = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	client = &tls.Config{RootCAs: pool, ServerName: "127.0.0.1"}
+	return server, client
+}
+
+func TestEmailNotifier_Send(t *testing.T) {
+	s := newSMTPStandIn(t, nil, false)
+
+	n := NewEmailNotifier("127.0.0.1", s.port(), "nf <nf@example.com>", []string{"ops@example.com", "Dev <dev@example.com>"})
+	n.Security = EmailSecurityNone
+	n.Username, n.Password = "nf", "secret"
+
+	event := Event{
+		Command:  "make",
+		Args:     []string{"release"},
+		Outcome:  OutcomeFailed,
+		ExitCode: 2,
+		Duration: 90 * time.Second,
+		Host:     "batch01",
+		Output:   "step 1\nerror: <disk full>\n",
+	}
+	require.NoError(t, n.Send(context.Background(), event))
+
+	s.mu.Lock()
+	assert.Equal(t, "<nf@example.com>", s.from)
+	assert.Equal(t, []string{"<ops@example.com>", "<dev@example.com>"}, s.rcpts)
+	assert.Equal(t, "nf", s.authUser)
+	assert.Equal(t, "PLAIN", s.authMech)
+	s.mu.Unlock()
+
+	msg := s.message(t)
+	assert.Equal(t, "Command Failed: make", msg.Header.Get("Subject"))
+	assert.Equal(t, "ops@example.com, Dev <dev@example.com>", msg.Header.Get("To"))
+	assert.NotEmpty(t, msg.Header.Get("Message-Id"))
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/mixed", mediaType)
+
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	bodies, err := parts.NextPart()
+	require.NoError(t, err)
+	_, altParams, err := mime.ParseMediaType(bodies.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	alternative := multipart.NewReader(bodies, altParams["boundary"])
+
+	// multipart.Reader decodes quoted-printable parts transparently.
+	text, err := alternative.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain; charset=utf-8", text.Header.Get("Content-Type"))
+	textBody, _ := io.ReadAll(text)
+	assert.Contains(t, string(textBody), "Command `make release` failed with exit code 2 after 90.00 seconds.")
+	assert.Contains(t, string(textBody), "Exit Code: 2\n")
+	assert.Contains(t, string(textBody), "Host: batch01\n")
+
+	htmlPart, err := alternative.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "text/html; charset=utf-8", htmlPart.Header.Get("Content-Type"))
+	htmlBody, _ := io.ReadAll(htmlPart)
+	assert.Contains(t, string(htmlBody), `<tr><th align="left">Command</th><td><code>make release</code></td></tr>`)
+	assert.Contains(t, string(htmlBody), `<tr><th align="left">Duration</th><td>1m30s</td></tr>`)
+
+	attachment, err := parts.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "output.log", attachment.FileName())
+	encoded, _ := io.ReadAll(attachment)
+	decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(encoded), "\r\n", ""))
+	require.NoError(t, err)
+	assert.Equal(t, event.Output, string(decoded))
+}
+
+func TestEmailNotifier_Security(t *testing.T) {
+	serverTLS, clientTLS := newTestTLSConfigs(t)
+
+	testCases := []struct {
+		name        string
+		security    string
+		auth        string
+		implicitTLS bool
+		serverTLS   *tls.Config
+		expectTLS   bool
+		expectedErr string
+	}{
+		{name: "starttls with login", security: EmailSecuritySTARTTLS, auth: EmailAuthLogin, serverTLS: serverTLS, expectTLS: true},
+		{name: "implicit tls with cram-md5", security: EmailSecurityTLS, auth: EmailAuthCRAMMD5, implicitTLS: true, serverTLS: serverTLS, expectTLS: true},
+		{name: "plain text with login", security: EmailSecurityNone, auth: EmailAuthLogin},
+		{name: "starttls not offered", security: EmailSecuritySTARTTLS, auth: EmailAuthPlain, expectedErr: "server does not support STARTTLS"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newSMTPStandIn(t, tc.serverTLS, tc.implicitTLS)
+
+			n := NewEmailNotifier("127.0.0.1", s.port(), "nf@example.com", []string{"ops@example.com"})
+			n.Security, n.Auth = tc.security, tc.auth
+			n.Username, n.Password = "nf", "secret"
+			n.TLSConfig = clientTLS
+
+			err := n.Notify("Test Title", "Test Message")
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			assert.Equal(t, tc.expectTLS, s.sawTLS)
+			assert.Equal(t, "nf", s.authUser)
+			assert.Equal(t, strings.ToUpper(tc.auth), s.authMech)
+		})
+	}
+}
+
+func TestEmailNotifier_AuthFailure(t *testing.T) {
+	s := newSMTPStandIn(t, nil, false)
+
+	n := NewEmailNotifier("127.0.0.1", s.port(), "nf@example.com", []string{"ops@example.com"})
+	n.Security = EmailSecurityNone
+	n.Username, n.Password = "nf", "wrong"
+
+	err := n.Notify("Test Title", "Test Message")
+	assert.ErrorContains(t, err, "535")
+}
+
+func TestLoginAuth_RefusesPlainText(t *testing.T) {
+	auth := &loginAuth{username: "nf", password: "secret", host: "mail.example.com"}
+	_, _, err := auth.Start(&smtp.ServerInfo{Name: "mail.example.com", TLS: false})
+	assert.EqualError(t, err, "unencrypted connection")
+
+	mech, _, err := auth.Start(&smtp.ServerInfo{Name: "mail.example.com", TLS: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "LOGIN", mech)
+}
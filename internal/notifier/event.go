@@ -0,0 +1,264 @@
+package notifier
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Outcome classifies how a command finished.
+type Outcome string
+
+const (
+	// OutcomeSucceeded means the command exited with code 0.
+	OutcomeSucceeded Outcome = "succeeded"
+	// OutcomeFailed means the command exited with a non-zero code.
+	OutcomeFailed Outcome = "failed"
+	// OutcomeKilled means the command was terminated by a signal.
+	OutcomeKilled Outcome = "killed"
+	// OutcomeInterrupted means nf was asked to stop the command, e.g. by
+	// Ctrl-C or a SIGTERM from a CI runner, and passed the signal on.
+	OutcomeInterrupted Outcome = "interrupted"
+	// OutcomeTimedOut means nf stopped the command because it ran past its timeout.
+	OutcomeTimedOut Outcome = "timed_out"
+	// OutcomeRunning means the command has not finished yet; it is used for
+	// heartbeat notifications.
+	OutcomeRunning Outcome = "running"
+	// OutcomeMatched means the still running command printed a line matching
+	// one of the on_match patterns. The line is in Output.
+	OutcomeMatched Outcome = "matched"
+	// OutcomeUnknown means the exit status is not available, e.g. in daemon mode.
+	OutcomeUnknown Outcome = "unknown"
+)
+
+// Event describes a finished command. It is passed to every notifier so that
+// each backend can render the details natively instead of parsing prose.
+// Heartbeats describe a command that is still running, with OutcomeRunning.
+type Event struct {
+	// Command is the executable that was run. In daemon mode, where the
+	// shell only reports the full command line, it holds the whole line.
+	Command string
+	// Args are the arguments passed to Command.
+	Args []string
+
+	Outcome Outcome
+	// ExitCode is the exit code of the command, or 128+signal if it was killed.
+	ExitCode int
+	// Signal is the name of the signal that killed or interrupted the
+	// command, e.g. "SIGTERM".
+	Signal string
+
+	Duration  time.Duration
+	StartTime time.Time
+	EndTime   time.Time
+
+	// Timeout is the deadline the command exceeded, if it timed out.
+	Timeout time.Duration
+
+	// Pattern is the on_match pattern that matched, for OutcomeMatched.
+	Pattern string
+
+	// Host is the hostname of the machine the command ran on.
+	Host string
+	// Dir is the working directory the command ran in.
+	Dir string
+	// User is the name of the user nf runs as.
+	User string
+
+	// Output holds the last lines of the command's captured output, or is
+	// empty if output capture is disabled.
+	Output string
+}
+
+// Field is a single labelled piece of event metadata, rendered as a Slack
+// field, a Teams fact and so on.
+type Field struct {
+	Name  string
+	Value string
+}
+
+// maxTitleCommandLength limits how much of the command line appears in a title.
+const maxTitleCommandLength = 50
+
+// CommandLine returns the command and its arguments joined by spaces.
+func (e Event) CommandLine() string {
+	return strings.TrimSpace(strings.Join(append([]string{e.Command}, e.Args...), " "))
+}
+
+// HasExitCode reports whether ExitCode is meaningful, i.e. the command has
+// finished and its exit status is known.
+func (e Event) HasExitCode() bool {
+	switch e.Outcome {
+	case OutcomeUnknown, OutcomeRunning, OutcomeMatched:
+		return false
+	default:
+		return true
+	}
+}
+
+// Success reports whether the command is known to have succeeded.
+func (e Event) Success() bool {
+	return e.Outcome == OutcomeSucceeded
+}
+
+// Title returns a short summary such as "Command Failed: make".
+func (e Event) Title() string {
+	var prefix string
+	switch e.Outcome {
+	case OutcomeSucceeded:
+		prefix = "Command Succeeded"
+	case OutcomeFailed:
+		prefix = "Command Failed"
+	case OutcomeKilled:
+		prefix = "Command Killed"
+	case OutcomeInterrupted:
+		prefix = "Command Interrupted"
+	case OutcomeTimedOut:
+		prefix = "Command Timed Out"
+	case OutcomeRunning:
+		prefix = "Command Still Running"
+	case OutcomeMatched:
+		prefix = "Output Matched"
+	default:
+		prefix = "Command Finished"
+	}
+
+	command := e.Command
+	if command == "" {
+		return prefix
+	}
+	if len(command) > maxTitleCommandLength {
+		command = truncateUTF8(command, maxTitleCommandLength) + "..."
+	}
+	return fmt.Sprintf("%s: %s", prefix, command)
+}
+
+// Message returns a one-sentence description of how the command finished.
+func (e Event) Message() string {
+	seconds := e.Duration.Seconds()
+	switch e.Outcome {
+	case OutcomeSucceeded:
+		return fmt.Sprintf("Command `%s` succeeded in %.2f seconds.", e.CommandLine(), seconds)
+	case OutcomeFailed, OutcomeKilled, OutcomeInterrupted:
+		return fmt.Sprintf("Command `%s` %s after %.2f seconds.", e.CommandLine(), e.StatusText(), seconds)
+	case OutcomeTimedOut:
+		return fmt.Sprintf("Command `%s` %s.", e.CommandLine(), e.StatusText())
+	case OutcomeRunning:
+		return fmt.Sprintf("Command `%s` is still running after %s.", e.CommandLine(), e.Duration.Round(time.Second))
+	case OutcomeMatched:
+		return fmt.Sprintf("Command `%s` printed output matching `%s` after %s.", e.CommandLine(), e.Pattern, e.Duration.Round(time.Second))
+	default:
+		return fmt.Sprintf("Command `%s` finished in %.2f seconds. Its exit code is not available.", e.CommandLine(), seconds)
+	}
+}
+
+// statusEmoji returns an emoji for the event's outcome, for notifiers
+// that show it next to the title.
+func statusEmoji(event Event) string {
+	switch event.Outcome {
+	case OutcomeSucceeded:
+		return "✅"
+	case OutcomeFailed:
+		return "❌"
+	case OutcomeKilled:
+		return "💀"
+	case OutcomeTimedOut:
+		return "⌛"
+	case OutcomeInterrupted:
+		return "⚠️"
+	case OutcomeRunning:
+		return "⏳"
+	case OutcomeMatched:
+		return "🔍"
+	default:
+		return "🔔"
+	}
+}
+
+// StatusText describes the outcome, e.g. "failed with exit code 2".
+func (e Event) StatusText() string {
+	switch e.Outcome {
+	case OutcomeSucceeded:
+		return "succeeded"
+	case OutcomeFailed:
+		return fmt.Sprintf("failed with exit code %d", e.ExitCode)
+	case OutcomeKilled:
+		return fmt.Sprintf("was killed by %s", e.Signal)
+	case OutcomeInterrupted:
+		return fmt.Sprintf("was interrupted by %s", e.Signal)
+	case OutcomeTimedOut:
+		return fmt.Sprintf("timed out after %s", e.Timeout)
+	case OutcomeRunning:
+		return "still running"
+	case OutcomeMatched:
+		return "output matched"
+	default:
+		return "finished"
+	}
+}
+
+// OutputTail returns the last lines of the captured output, limited to at
+// most maxLines lines and maxBytes bytes. A limit of zero or less means
+// unlimited. If the output had to be cut mid-line, it starts with "...".
+func (e Event) OutputTail(maxLines, maxBytes int) string {
+	output := strings.TrimRight(e.Output, "\n")
+	if maxLines > 0 {
+		lines := strings.Split(output, "\n")
+		if len(lines) > maxLines {
+			output = strings.Join(lines[len(lines)-maxLines:], "\n")
+		}
+	}
+	if maxBytes > 0 && len(output) > maxBytes {
+		output = output[len(output)-maxBytes:]
+		// Prefer starting at a line boundary if there is one.
+		if i := strings.IndexByte(output, '\n'); i >= 0 && i < len(output)-1 {
+			output = output[i+1:]
+		} else {
+			output = "..." + strings.ToValidUTF8(output, "")
+		}
+	}
+	return output
+}
+
+// truncateUTF8 returns the longest prefix of s that is at most maxBytes bytes
+// long and does not split a UTF-8 sequence.
+func truncateUTF8(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	for maxBytes > 0 && !utf8.RuneStart(s[maxBytes]) {
+		maxBytes--
+	}
+	return s[:maxBytes]
+}
+
+// Fields returns the event metadata as an ordered list of labelled values.
+// Fields whose value is unknown are omitted.
+func (e Event) Fields() []Field {
+	fields := []Field{
+		{Name: "Command", Value: e.CommandLine()},
+		{Name: "Status", Value: e.StatusText()},
+	}
+	if e.HasExitCode() {
+		fields = append(fields, Field{Name: "Exit Code", Value: strconv.Itoa(e.ExitCode)})
+	}
+	if e.Pattern != "" {
+		fields = append(fields, Field{Name: "Pattern", Value: e.Pattern})
+	}
+	fields = append(fields, Field{Name: "Duration", Value: e.Duration.Round(time.Millisecond).String()})
+	if e.Host != "" {
+		fields = append(fields, Field{Name: "Host", Value: e.Host})
+	}
+	if e.Dir != "" {
+		fields = append(fields, Field{Name: "Directory", Value: e.Dir})
+	}
+	if !e.StartTime.IsZero() {
+		fields = append(fields, Field{Name: "Started", Value: e.StartTime.Format(time.RFC3339)})
+	}
+	if !e.EndTime.IsZero() {
+		fields = append(fields, Field{Name: "Finished", Value: e.EndTime.Format(time.RFC3339)})
+	}
+	return fields
+}
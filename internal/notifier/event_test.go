@@ -0,0 +1,84 @@
+package notifier
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvent_TitleAndMessage(t *testing.T) {
+	testCases := []struct {
+		name            string
+		event           Event
+		expectedTitle   string
+		expectedMessage string
+	}{
+		{
+			name:            "succeeded",
+			event:           Event{Command: "make", Args: []string{"build"}, Outcome: OutcomeSucceeded, Duration: 12 * time.Second},
+			expectedTitle:   "Command Succeeded: make",
+			expectedMessage: "Command `make build` succeeded in 12.00 seconds.",
+		},
+		{
+			name:            "failed",
+			event:           Event{Command: "make", Outcome: OutcomeFailed, ExitCode: 2, Duration: 3 * time.Second},
+			expectedTitle:   "Command Failed: make",
+			expectedMessage: "Command `make` failed with exit code 2 after 3.00 seconds.",
+		},
+		{
+			name:            "killed",
+			event:           Event{Command: "make", Outcome: OutcomeKilled, ExitCode: 143, Signal: "SIGTERM", Duration: time.Second},
+			expectedTitle:   "Command Killed: make",
+			expectedMessage: "Command `make` was killed by SIGTERM after 1.00 seconds.",
+		},
+		{
+			name:            "unknown outcome with long command line",
+			event:           Event{Command: "find / -name '*.go' -newer /tmp/marker -exec wc -l {} +", Outcome: OutcomeUnknown, Duration: 15 * time.Second},
+			expectedTitle:   "Command Finished: find / -name '*.go' -newer /tmp/marker -exec wc -l...",
+			expectedMessage: "Command `find / -name '*.go' -newer /tmp/marker -exec wc -l {} +` finished in 15.00 seconds. Its exit code is not available.",
+		},
+		{
+			name:            "long command line with multi-byte characters",
+			event:           Event{Command: strings.Repeat("a", 49) + "é-and-more", Outcome: OutcomeSucceeded},
+			expectedTitle:   "Command Succeeded: " + strings.Repeat("a", 49) + "...",
+			expectedMessage: "Command `" + strings.Repeat("a", 49) + "é-and-more` succeeded in 0.00 seconds.",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedTitle, tc.event.Title())
+			assert.Equal(t, tc.expectedMessage, tc.event.Message())
+		})
+	}
+}
+
+func TestEvent_Fields(t *testing.T) {
+	event := Event{Command: "sleep", Args: []string{"20"}, Outcome: OutcomeUnknown, Duration: 20 * time.Second, Host: "laptop"}
+
+	assert.Equal(t, []Field{
+		{Name: "Command", Value: "sleep 20"},
+		{Name: "Status", Value: "finished"},
+		{Name: "Duration", Value: "20s"},
+		{Name: "Host", Value: "laptop"},
+	}, event.Fields())
+}
+
+func TestEvent_OutputTail(t *testing.T) {
+	event := Event{Output: "line 1\nline 2\nline 3\nline 4\n"}
+
+	assert.Equal(t, "line 1\nline 2\nline 3\nline 4", event.OutputTail(0, 0))
+	assert.Equal(t, "line 3\nline 4", event.OutputTail(2, 0))
+	assert.Equal(t, "line 4", event.OutputTail(0, 10))
+	assert.Equal(t, "...ne 4", event.OutputTail(1, 4))
+	assert.Equal(t, "", Event{}.OutputTail(3, 100))
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	assert.Equal(t, "short", truncateUTF8("short", 10))
+	assert.Equal(t, "ab", truncateUTF8("abé", 3), "Expected the two-byte rune to be dropped, not split")
+	assert.Equal(t, "abé", truncateUTF8("abéd", 4))
+	assert.Equal(t, "", truncateUTF8("日本", 2))
+}
@@ -0,0 +1,122 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultExecTimeout is how long ExecNotifier waits for its program unless
+// configured otherwise.
+const DefaultExecTimeout = 30 * time.Second
+
+// execStderrBytes limits how much of the program's stderr is reported when
+// it fails.
+const execStderrBytes = 1024
+
+// ExecNotifier runs a program for every notification. The event is passed
+// as NF_* environment variables and as JSON on stdin, in the same format
+// the app notifier sends.
+type ExecNotifier struct {
+	// Command is the program followed by its arguments.
+	Command []string
+	// Timeout stops the program if it runs longer.
+	Timeout time.Duration
+}
+
+// NewExecNotifier creates a new instance of ExecNotifier.
+func NewExecNotifier(command []string) *ExecNotifier {
+	return &ExecNotifier{Command: command, Timeout: DefaultExecTimeout}
+}
+
+// Send runs the program with the event and returns an error including the
+// end of its stderr if it fails.
+func (e *ExecNotifier) Send(ctx context.Context, event Event) error {
+	if len(e.Command) == 0 {
+		return errors.New("failed to run exec notifier: no command")
+	}
+	input, err := json.Marshal(newAppPayload(event))
+	if err != nil {
+		return fmt.Errorf("failed to marshal exec payload: %w", err)
+	}
+
+	if e.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.Timeout)
+		defer cancel()
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.Command[0], e.Command[1:]...)
+	cmd.Env = append(os.Environ(), execEnv(event)...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stderr = &stderr
+	// Background processes the program leaves behind may hold on to stderr;
+	// do not wait for them once the program itself is done.
+	cmd.WaitDelay = time.Second
+
+	err = cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("failed to run exec notifier: %s timed out after %s", e.Command[0], e.Timeout)
+	}
+	if err != nil {
+		if msg := lastBytes(strings.TrimSpace(stderr.String()), execStderrBytes); msg != "" {
+			return fmt.Errorf("failed to run exec notifier: %s: %w: %s", e.Command[0], err, msg)
+		}
+		return fmt.Errorf("failed to run exec notifier: %s: %w", e.Command[0], err)
+	}
+	return nil
+}
+
+// execEnv returns the event as NF_* environment variables. Variables whose
+// value is unknown are set to an empty string, so that values inherited
+// from nf's own environment do not leak through.
+func execEnv(event Event) []string {
+	var exitCode, startedAt, finishedAt string
+	if event.HasExitCode() {
+		exitCode = strconv.Itoa(event.ExitCode)
+	}
+	if !event.StartTime.IsZero() {
+		startedAt = event.StartTime.Format(time.RFC3339)
+	}
+	if !event.EndTime.IsZero() {
+		finishedAt = event.EndTime.Format(time.RFC3339)
+	}
+	return []string{
+		"NF_TITLE=" + event.Title(),
+		"NF_MESSAGE=" + event.Message(),
+		"NF_COMMAND=" + event.CommandLine(),
+		"NF_OUTCOME=" + string(event.Outcome),
+		"NF_STATUS=" + event.StatusText(),
+		"NF_EXIT_CODE=" + exitCode,
+		"NF_SIGNAL=" + event.Signal,
+		"NF_PATTERN=" + event.Pattern,
+		"NF_DURATION=" + strconv.FormatFloat(event.Duration.Seconds(), 'f', 3, 64),
+		"NF_STARTED_AT=" + startedAt,
+		"NF_FINISHED_AT=" + finishedAt,
+		"NF_HOST=" + event.Host,
+		"NF_USER=" + event.User,
+		"NF_CWD=" + event.Dir,
+	}
+}
+
+// lastBytes returns at most the last n bytes of s, starting at the
+// beginning of a UTF-8 character.
+func lastBytes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	start := len(s) - n
+	for start < len(s) && !utf8.RuneStart(s[start]) {
+		start++
+	}
+	return "..." + s[start:]
+}
@@ -0,0 +1,88 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecNotifier_Send(t *testing.T) {
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, "env")
+	stdinFile := filepath.Join(dir, "stdin")
+	t.Setenv("NF_SIGNAL", "inherited")
+
+	event := Event{
+		Command:  "make",
+		Args:     []string{"test"},
+		Outcome:  OutcomeFailed,
+		ExitCode: 2,
+		Duration: 1500 * time.Millisecond,
+		Host:     "labbox",
+		User:     "jules",
+		Dir:      "/src/nf",
+		Output:   "FAIL: TestParse",
+	}
+
+	n := NewExecNotifier([]string{"sh", "-c", `env | grep ^NF_ | sort > "$1"; cat > "$2"`, "sh", envFile, stdinFile})
+	require.NoError(t, n.Send(context.Background(), event))
+
+	env, err := os.ReadFile(envFile)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"NF_COMMAND=make test",
+		"NF_CWD=/src/nf",
+		"NF_DURATION=1.500",
+		"NF_EXIT_CODE=2",
+		"NF_FINISHED_AT=",
+		"NF_HOST=labbox",
+		"NF_MESSAGE=Command `make test` failed with exit code 2 after 1.50 seconds.",
+		"NF_OUTCOME=failed",
+		"NF_PATTERN=",
+		"NF_SIGNAL=",
+		"NF_STARTED_AT=",
+		"NF_STATUS=failed with exit code 2",
+		"NF_TITLE=Command Failed: make",
+		"NF_USER=jules",
+	}, strings.Split(strings.TrimSpace(string(env)), "\n"))
+
+	stdin, err := os.ReadFile(stdinFile)
+	require.NoError(t, err)
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal(stdin, &payload), "Expected JSON on stdin")
+	assert.Equal(t, "failed", payload["outcome"])
+	assert.Equal(t, float64(2), payload["exit_code"])
+	assert.Equal(t, "FAIL: TestParse", payload["output"])
+}
+
+func TestExecNotifier_Errors(t *testing.T) {
+	event := Event{Command: "make", Outcome: OutcomeSucceeded}
+
+	err := NewExecNotifier([]string{"sh", "-c", "echo 'light not found' >&2; exit 3"}).Send(context.Background(), event)
+	assert.EqualError(t, err, "failed to run exec notifier: sh: exit status 3: light not found")
+
+	err = NewExecNotifier([]string{filepath.Join(t.TempDir(), "missing")}).Send(context.Background(), event)
+	assert.ErrorContains(t, err, "failed to run exec notifier")
+
+	n := NewExecNotifier([]string{"sleep", "10"})
+	n.Timeout = 50 * time.Millisecond
+	start := time.Now()
+	err = n.Send(context.Background(), event)
+	assert.EqualError(t, err, "failed to run exec notifier: sleep timed out after 50ms")
+	assert.Less(t, time.Since(start), 5*time.Second, "Expected the program to be stopped on timeout")
+}
+
+func TestLastBytes(t *testing.T) {
+	assert.Equal(t, "short", lastBytes("short", 10))
+	assert.Equal(t, "...ror", lastBytes("error", 3))
+	assert.Equal(t, "...b", lastBytes("éb", 2), "Expected the two-byte rune to be dropped, not split")
+}
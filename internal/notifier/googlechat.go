@@ -0,0 +1,216 @@
+package notifier
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+)
+
+// GoogleChatNotifier sends notifications to a Google Chat space webhook.
+type GoogleChatNotifier struct {
+	WebhookURL string
+	// LogURL, if set, adds a button linking to it, e.g. to CI logs.
+	LogURL string
+	// Thread replies in one thread per command line, so that repeated runs
+	// of the same command are grouped together.
+	Thread bool
+}
+
+// NewGoogleChatNotifier creates a new instance of GoogleChatNotifier.
+func NewGoogleChatNotifier(webhookURL string) *GoogleChatNotifier {
+	return &GoogleChatNotifier{WebhookURL: webhookURL}
+}
+
+// googleChatMessage is the JSON structure for a Google Chat message.
+type googleChatMessage struct {
+	Text         string            `json:"text,omitempty"`
+	FallbackText string            `json:"fallbackText,omitempty"`
+	CardsV2      []googleChatCard  `json:"cardsV2,omitempty"`
+	Thread       *googleChatThread `json:"thread,omitempty"`
+}
+
+// googleChatThread identifies the thread a message replies to.
+type googleChatThread struct {
+	ThreadKey string `json:"threadKey"`
+}
+
+// googleChatCard is a card in the cards v2 format.
+type googleChatCard struct {
+	CardID string `json:"cardId"`
+	Card   struct {
+		Header   googleChatHeader    `json:"header"`
+		Sections []googleChatSection `json:"sections"`
+	} `json:"card"`
+}
+
+type googleChatHeader struct {
+	Title    string `json:"title"`
+	Subtitle string `json:"subtitle,omitempty"`
+}
+
+type googleChatSection struct {
+	Header      string             `json:"header,omitempty"`
+	Collapsible bool               `json:"collapsible,omitempty"`
+	Widgets     []googleChatWidget `json:"widgets"`
+}
+
+// googleChatWidget holds exactly one of its fields.
+type googleChatWidget struct {
+	DecoratedText *googleChatDecoratedText `json:"decoratedText,omitempty"`
+	TextParagraph *googleChatText          `json:"textParagraph,omitempty"`
+	ButtonList    *googleChatButtonList    `json:"buttonList,omitempty"`
+}
+
+type googleChatDecoratedText struct {
+	TopLabel  string          `json:"topLabel"`
+	Text      string          `json:"text"`
+	WrapText  bool            `json:"wrapText,omitempty"`
+	StartIcon *googleChatIcon `json:"startIcon,omitempty"`
+}
+
+type googleChatIcon struct {
+	MaterialIcon struct {
+		Name string `json:"name"`
+	} `json:"materialIcon"`
+}
+
+type googleChatText struct {
+	Text string `json:"text"`
+}
+
+type googleChatButtonList struct {
+	Buttons []googleChatButton `json:"buttons"`
+}
+
+type googleChatButton struct {
+	Text    string `json:"text"`
+	OnClick struct {
+		OpenLink struct {
+			URL string `json:"url"`
+		} `json:"openLink"`
+	} `json:"onClick"`
+}
+
+// googleChatOutputBytes limits the captured output included in a card.
+const googleChatOutputBytes = 3000
+
+// googleChatIcons maps field names to the Material icons shown next to them.
+var googleChatIcons = map[string]string{
+	"Status":    "info",
+	"Exit Code": "tag",
+	"Pattern":   "search",
+	"Duration":  "timer",
+	"Host":      "dns",
+	"Directory": "folder",
+}
+
+// Notify sends a plain text message to the configured Google Chat webhook.
+func (g *GoogleChatNotifier) Notify(title, message string) error {
+	return g.post(context.Background(), googleChatMessage{Text: fmt.Sprintf("*%s*\n%s", title, message)}, "")
+}
+
+// Send sends the event to the configured Google Chat webhook as a card with
+// a status icon in its header, the event metadata as decorated text
+// widgets, and any captured output in a collapsible section.
+func (g *GoogleChatNotifier) Send(ctx context.Context, event Event) error {
+	card := googleChatCard{CardID: "nf"}
+	card.Card.Header = googleChatHeader{
+		Title:    statusEmoji(event) + " " + event.Title(),
+		Subtitle: event.CommandLine(),
+	}
+
+	var details googleChatSection
+	for _, f := range event.Fields() {
+		icon, ok := googleChatIcons[f.Name]
+		if !ok {
+			// The command is already in the header, and the start and end
+			// times add little to the duration.
+			continue
+		}
+		text := &googleChatDecoratedText{TopLabel: f.Name, Text: html.EscapeString(f.Value), WrapText: true}
+		text.StartIcon = &googleChatIcon{}
+		text.StartIcon.MaterialIcon.Name = icon
+		details.Widgets = append(details.Widgets, googleChatWidget{DecoratedText: text})
+	}
+	if g.LogURL != "" {
+		button := googleChatButton{Text: "View log"}
+		button.OnClick.OpenLink.URL = g.LogURL
+		details.Widgets = append(details.Widgets, googleChatWidget{
+			ButtonList: &googleChatButtonList{Buttons: []googleChatButton{button}},
+		})
+	}
+	card.Card.Sections = append(card.Card.Sections, details)
+
+	if tail := event.OutputTail(0, googleChatOutputBytes); tail != "" {
+		card.Card.Sections = append(card.Card.Sections, googleChatSection{
+			Header:      "Output",
+			Collapsible: true,
+			Widgets: []googleChatWidget{{
+				TextParagraph: &googleChatText{Text: html.EscapeString(tail)},
+			}},
+		})
+	}
+
+	message := googleChatMessage{
+		FallbackText: event.Title(),
+		CardsV2:      []googleChatCard{card},
+	}
+	var threadKey string
+	if g.Thread {
+		threadKey = googleChatThreadKey(event)
+	}
+	return g.post(ctx, message, threadKey)
+}
+
+// googleChatThreadKey derives a thread key from the command line, so every
+// run of the same command replies to the same thread.
+func googleChatThreadKey(event Event) string {
+	sum := sha256.Sum256([]byte(event.CommandLine()))
+	return "nf-" + hex.EncodeToString(sum[:12])
+}
+
+// post sends the message, in the thread with the given key if it is not
+// empty.
+func (g *GoogleChatNotifier) post(ctx context.Context, message googleChatMessage, threadKey string) error {
+	endpoint := g.WebhookURL
+	if threadKey != "" {
+		u, err := url.Parse(g.WebhookURL)
+		if err != nil {
+			return fmt.Errorf("invalid google chat webhook URL: %w", err)
+		}
+		q := u.Query()
+		q.Set("messageReplyOption", "REPLY_MESSAGE_FALLBACK_TO_NEW_THREAD")
+		u.RawQuery = q.Encode()
+		endpoint = u.String()
+		message.Thread = &googleChatThread{ThreadKey: threadKey}
+	}
+
+	payloadBytes, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal google chat payload: %w", err)
+	}
+
+	_, err = postJSON(ctx, endpoint, map[string]string{"Content-Type": "application/json; charset=UTF-8"}, payloadBytes)
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		var body struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		json.Unmarshal(statusErr.Body, &body)
+		if msg := strings.TrimSpace(body.Error.Message); msg != "" {
+			return fmt.Errorf("failed to send google chat notification: %w: %s", err, msg)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to send google chat notification: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoogleChatNotifier_Send(t *testing.T) {
+	event := Event{
+		Command:  "make",
+		Args:     []string{"test"},
+		Outcome:  OutcomeFailed,
+		ExitCode: 2,
+		Duration: 90 * time.Second,
+		Host:     "labbox",
+		Dir:      "/src/nf",
+		Output:   "FAIL: <TestParse>",
+	}
+
+	var payloads []googleChatMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method, "Expected POST request")
+		assert.Equal(t, "secret", r.URL.Query().Get("key"))
+		assert.Equal(t, "REPLY_MESSAGE_FALLBACK_TO_NEW_THREAD", r.URL.Query().Get("messageReplyOption"))
+
+		bodyBytes, err := io.ReadAll(r.Body)
+		require.NoError(t, err, "Failed to read request body")
+
+		var payload googleChatMessage
+		require.NoError(t, json.Unmarshal(bodyBytes, &payload), "Failed to unmarshal request body")
+		payloads = append(payloads, payload)
+
+		w.Write([]byte(`{"name": "spaces/AAA/messages/BBB"}`))
+	}))
+	defer server.Close()
+
+	n := NewGoogleChatNotifier(server.URL + "/v1/spaces/AAA/messages?key=secret")
+	n.LogURL = "https://ci.example.com/42"
+	n.Thread = true
+	require.NoError(t, n.Send(context.Background(), event), "Send returned an unexpected error")
+	event.Outcome, event.ExitCode, event.Output = OutcomeSucceeded, 0, ""
+	require.NoError(t, n.Send(context.Background(), event), "Send returned an unexpected error")
+	event.Args = []string{"lint"}
+	require.NoError(t, n.Send(context.Background(), event), "Send returned an unexpected error")
+	require.Len(t, payloads, 3)
+
+	payload := payloads[0]
+	assert.Equal(t, "Command Failed: make", payload.FallbackText)
+	require.Len(t, payload.CardsV2, 1)
+	card := payload.CardsV2[0].Card
+	assert.Equal(t, googleChatHeader{Title: "❌ Command Failed: make", Subtitle: "make test"}, card.Header)
+
+	require.Len(t, card.Sections, 2)
+	var labels []string
+	for _, w := range card.Sections[0].Widgets {
+		if w.DecoratedText != nil {
+			labels = append(labels, w.DecoratedText.TopLabel+"="+w.DecoratedText.Text+" ("+w.DecoratedText.StartIcon.MaterialIcon.Name+")")
+		}
+	}
+	assert.Equal(t, []string{
+		"Status=failed with exit code 2 (info)",
+		"Exit Code=2 (tag)",
+		"Duration=1m30s (timer)",
+		"Host=labbox (dns)",
+		"Directory=/src/nf (folder)",
+	}, labels)
+	buttons := card.Sections[0].Widgets[len(card.Sections[0].Widgets)-1].ButtonList
+	require.NotNil(t, buttons)
+	assert.Equal(t, "https://ci.example.com/42", buttons.Buttons[0].OnClick.OpenLink.URL)
+
+	assert.True(t, card.Sections[1].Collapsible)
+	assert.Equal(t, "FAIL: &lt;TestParse&gt;", card.Sections[1].Widgets[0].TextParagraph.Text)
+
+	assert.Equal(t, "✅ Command Succeeded: make", payloads[1].CardsV2[0].Card.Header.Title)
+	assert.Len(t, payloads[1].CardsV2[0].Card.Sections, 1, "Expected no output section without output")
+
+	for _, p := range payloads {
+		require.NotNil(t, p.Thread)
+	}
+	assert.Equal(t, payloads[0].Thread.ThreadKey, payloads[1].Thread.ThreadKey, "Expected runs of the same command to share a thread")
+	assert.NotEqual(t, payloads[0].Thread.ThreadKey, payloads[2].Thread.ThreadKey, "Expected a different command to get its own thread")
+}
+
+func TestGoogleChatNotifier_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.URL.Query().Get("messageReplyOption"))
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": {"code": 400, "message": "Invalid JSON payload received.", "status": "INVALID_ARGUMENT"}}`))
+	}))
+	defer server.Close()
+
+	err := NewGoogleChatNotifier(server.URL).Notify("Test Title", "Test Message")
+	assert.EqualError(t, err, "failed to send google chat notification: received status code 400: Invalid JSON payload received.")
+}
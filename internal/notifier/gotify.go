@@ -0,0 +1,111 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// GotifyNotifier sends notifications to a Gotify server.
+type GotifyNotifier struct {
+	ServerURL string
+	// AppToken is the token of the Gotify application nf sends as.
+	AppToken string
+	// ClickURL is opened when the notification is clicked, if set.
+	ClickURL string
+}
+
+// NewGotifyNotifier creates a new instance of GotifyNotifier.
+func NewGotifyNotifier(serverURL, appToken string) *GotifyNotifier {
+	return &GotifyNotifier{ServerURL: serverURL, AppToken: appToken}
+}
+
+// gotifyPayload is the JSON structure for Gotify's message endpoint.
+type gotifyPayload struct {
+	Title    string                 `json:"title"`
+	Message  string                 `json:"message"`
+	Priority int                    `json:"priority"`
+	Extras   map[string]interface{} `json:"extras,omitempty"`
+}
+
+// Gotify message priorities. Clients show priorities of 8 and more
+// prominently, e.g. as a heads-up notification on Android.
+const (
+	gotifyPriorityLow     = 4
+	gotifyPriorityDefault = 5
+	gotifyPriorityHigh    = 8
+)
+
+// gotifyOutputBytes limits the captured output included in a message.
+const gotifyOutputBytes = 3000
+
+// Notify sends a message to the configured Gotify server.
+func (g *GotifyNotifier) Notify(title, message string) error {
+	return g.post(context.Background(), gotifyPayload{
+		Title:    title,
+		Message:  message,
+		Priority: gotifyPriorityDefault,
+	})
+}
+
+// Send sends the event to the configured Gotify server. The message is
+// rendered as markdown, with the event metadata as a list and any captured
+// output as a code block, and failures are sent with a high priority.
+func (g *GotifyNotifier) Send(ctx context.Context, event Event) error {
+	var b strings.Builder
+	b.WriteString(event.Message())
+	b.WriteString("\n")
+	for _, f := range event.Fields() {
+		if f.Name == "Command" {
+			continue
+		}
+		fmt.Fprintf(&b, "\n- **%s:** %s", f.Name, f.Value)
+	}
+	if tail := event.OutputTail(0, gotifyOutputBytes); tail != "" {
+		b.WriteString("\n\n" + slackCodeBlock(tail))
+	}
+
+	extras := map[string]interface{}{
+		"client::display": map[string]string{"contentType": "text/markdown"},
+	}
+	if g.ClickURL != "" {
+		extras["client::notification"] = map[string]interface{}{
+			"click": map[string]string{"url": g.ClickURL},
+		}
+	}
+
+	return g.post(ctx, gotifyPayload{
+		Title:    event.Title(),
+		Message:  b.String(),
+		Priority: gotifyPriority(event),
+		Extras:   extras,
+	})
+}
+
+// gotifyPriority returns the message priority for the event's outcome.
+func gotifyPriority(event Event) int {
+	switch event.Outcome {
+	case OutcomeSucceeded:
+		return gotifyPriorityLow
+	case OutcomeFailed, OutcomeKilled, OutcomeTimedOut:
+		return gotifyPriorityHigh
+	default:
+		return gotifyPriorityDefault
+	}
+}
+
+func (g *GotifyNotifier) post(ctx context.Context, payload gotifyPayload) error {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal gotify payload: %w", err)
+	}
+
+	url := strings.TrimRight(g.ServerURL, "/") + "/message"
+	// The token goes in a header rather than the query string so that it
+	// does not end up in proxy logs.
+	if _, err := postJSON(ctx, url, map[string]string{"X-Gotify-Key": g.AppToken}, payloadBytes); err != nil {
+		return fmt.Errorf("failed to send gotify notification: %w", err)
+	}
+	return nil
+}
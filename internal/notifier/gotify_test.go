@@ -0,0 +1,75 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGotifyNotifier_Send(t *testing.T) {
+	event := Event{
+		Command:  "make",
+		Args:     []string{"test"},
+		Outcome:  OutcomeFailed,
+		ExitCode: 2,
+		Duration: 90 * time.Second,
+		Host:     "labbox",
+		Output:   "FAIL: TestParse",
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method, "Expected POST request")
+		assert.Equal(t, "/gotify/message", r.URL.Path)
+		assert.Equal(t, "app-token", r.Header.Get("X-Gotify-Key"))
+
+		bodyBytes, err := io.ReadAll(r.Body)
+		require.NoError(t, err, "Failed to read request body")
+		defer r.Body.Close()
+
+		var payload map[string]interface{}
+		require.NoError(t, json.Unmarshal(bodyBytes, &payload), "Failed to unmarshal request body")
+
+		assert.Equal(t, "Command Failed: make", payload["title"])
+		assert.Equal(t, "Command `make test` failed with exit code 2 after 90.00 seconds.\n"+
+			"\n- **Status:** failed with exit code 2"+
+			"\n- **Exit Code:** 2"+
+			"\n- **Duration:** 1m30s"+
+			"\n- **Host:** labbox"+
+			"\n\n```\nFAIL: TestParse\n```", payload["message"])
+		assert.Equal(t, float64(8), payload["priority"])
+		assert.Equal(t, map[string]interface{}{
+			"client::display":      map[string]interface{}{"contentType": "text/markdown"},
+			"client::notification": map[string]interface{}{"click": map[string]interface{}{"url": "https://ci.example.com"}},
+		}, payload["extras"])
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	n := NewGotifyNotifier(server.URL+"/gotify/", "app-token")
+	n.ClickURL = "https://ci.example.com"
+	assert.NoError(t, n.Send(context.Background(), event), "Send returned an unexpected error")
+}
+
+func TestGotifyPriority(t *testing.T) {
+	assert.Equal(t, gotifyPriorityLow, gotifyPriority(Event{Outcome: OutcomeSucceeded}))
+	assert.Equal(t, gotifyPriorityHigh, gotifyPriority(Event{Outcome: OutcomeTimedOut}))
+	assert.Equal(t, gotifyPriorityDefault, gotifyPriority(Event{Outcome: OutcomeRunning}))
+}
+
+func TestGotifyNotifier_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	err := NewGotifyNotifier(server.URL, "wrong").Notify("Test Title", "Test Message")
+	assert.EqualError(t, err, "failed to send gotify notification: received status code 401")
+}
@@ -0,0 +1,82 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// httpTimeout bounds every request an HTTP notifier makes, so that a server
+// that accepts the connection but never answers cannot keep nf waiting.
+const httpTimeout = 30 * time.Second
+
+// httpClient is the client shared by all HTTP notifiers.
+var httpClient = &http.Client{Timeout: httpTimeout}
+
+// maxResponseBytes limits how much of a response body is read.
+const maxResponseBytes = 1 << 20
+
+// httpStatusError is returned for a response with a status code of 400 or
+// above. Many services explain the error in the body, so it is kept for the
+// notifier to decode.
+type httpStatusError struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("received status code %d", e.StatusCode)
+}
+
+// postJSON posts a JSON body to url and returns the response body. See
+// sendHTTP for how headers and errors are handled.
+func postJSON(ctx context.Context, url string, headers map[string]string, body []byte) ([]byte, error) {
+	return sendHTTP(ctx, http.MethodPost, url, headers, bytes.NewReader(body))
+}
+
+// sendHTTP sends a request and returns the response body. Content-Type
+// defaults to application/json and can be changed through headers. A status
+// code of 400 or above is returned as an *httpStatusError. Errors never
+// include the URL, since webhook URLs and bot API paths carry secrets.
+func sendHTTP(ctx context.Context, method, url string, headers map[string]string, body io.Reader) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create http request: %w", stripURLError(err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, stripURLError(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if resp.StatusCode >= 400 {
+		return nil, &httpStatusError{StatusCode: resp.StatusCode, Header: resp.Header, Body: data}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", stripURLError(err))
+	}
+	return data, nil
+}
+
+// stripURLError removes the request URL from an error returned by the HTTP
+// client. Webhook URLs and bot API paths carry the secret token, which would
+// otherwise end up in error messages and CI logs.
+func stripURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
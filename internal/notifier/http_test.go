@@ -0,0 +1,94 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method, "Expected POST request")
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+		bodyBytes, err := io.ReadAll(r.Body)
+		require.NoError(t, err, "Failed to read request body")
+		assert.Equal(t, `{"text":"hi"}`, string(bodyBytes))
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	body, err := postJSON(context.Background(), server.URL, map[string]string{"Authorization": "Bearer token"}, []byte(`{"text":"hi"}`))
+	require.NoError(t, err)
+	assert.Equal(t, `{"ok":true}`, string(body))
+}
+
+func TestSendHTTP_ContentType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PUT", r.Method, "Expected PUT request")
+		assert.Equal(t, "text/plain", r.Header.Get("Content-Type"))
+	}))
+	defer server.Close()
+
+	_, err := sendHTTP(context.Background(), "PUT", server.URL, map[string]string{"content-type": "text/plain"}, strings.NewReader("hi"))
+	assert.NoError(t, err)
+}
+
+func TestSendHTTP_StatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"retry_after": 3}`))
+	}))
+	defer server.Close()
+
+	_, err := postJSON(context.Background(), server.URL, nil, nil)
+	assert.EqualError(t, err, "received status code 429")
+
+	var statusErr *httpStatusError
+	require.True(t, errors.As(err, &statusErr), "Expected an *httpStatusError")
+	assert.Equal(t, http.StatusTooManyRequests, statusErr.StatusCode)
+	assert.Equal(t, "3", statusErr.Header.Get("Retry-After"))
+	assert.Equal(t, `{"retry_after": 3}`, string(statusErr.Body))
+}
+
+func TestSendHTTP_ErrorsHideURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	_, err := postJSON(context.Background(), server.URL+"/hooks/secret-token", nil, nil)
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret-token")
+
+	_, err = postJSON(context.Background(), "http://[::1/hooks/secret-token", nil, nil)
+	assert.ErrorContains(t, err, "failed to create http request")
+	assert.NotContains(t, err.Error(), "secret-token")
+}
+
+func TestSendHTTP_Timeout(t *testing.T) {
+	originalClient := httpClient
+	defer func() { httpClient = originalClient }()
+	httpClient = &http.Client{Timeout: 50 * time.Millisecond}
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	start := time.Now()
+	_, err := postJSON(context.Background(), server.URL+"/hooks/secret-token", nil, nil)
+	require.Error(t, err, "Expected a server that never answers to time out")
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.NotContains(t, err.Error(), "secret-token")
+}
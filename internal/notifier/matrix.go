@@ -0,0 +1,194 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// MatrixNotifier sends notifications to a Matrix room through the
+// client-server API.
+type MatrixNotifier struct {
+	HomeserverURL string
+	AccessToken   string
+	// RoomID is the ID of the room to send to, e.g. "!abc123:example.org".
+	RoomID string
+	// NoticeForSuccess sends notifications about successful commands as
+	// m.notice messages, which clients show less prominently and bots do
+	// not react to.
+	NoticeForSuccess bool
+}
+
+// NewMatrixNotifier creates a new instance of MatrixNotifier.
+func NewMatrixNotifier(homeserverURL, accessToken, roomID string) *MatrixNotifier {
+	return &MatrixNotifier{HomeserverURL: homeserverURL, AccessToken: accessToken, RoomID: roomID}
+}
+
+// matrixMessage is the content of an m.room.message event.
+type matrixMessage struct {
+	MsgType       string `json:"msgtype"`
+	Body          string `json:"body"`
+	Format        string `json:"format,omitempty"`
+	FormattedBody string `json:"formatted_body,omitempty"`
+}
+
+// matrixError is the error response of the client-server API.
+type matrixError struct {
+	ErrCode      string `json:"errcode"`
+	Error        string `json:"error"`
+	RetryAfterMS int64  `json:"retry_after_ms"`
+}
+
+// matrixOutputBytes limits the captured output included in a message.
+const matrixOutputBytes = 3000
+
+// matrixMaxAttempts is how often a message is tried in total when the
+// homeserver is rate limiting or temporarily failing, and
+// matrixMaxRetryAfter the longest wait between attempts nf accepts.
+const (
+	matrixMaxAttempts   = 3
+	matrixMaxRetryAfter = 30 * time.Second
+)
+
+// matrixRetryDelay is how long to wait before retrying if the homeserver
+// does not say. It is a variable so that tests can shorten it.
+var matrixRetryDelay = time.Second
+
+// Notify sends a message to the configured room.
+func (m *MatrixNotifier) Notify(title, message string) error {
+	return m.send(context.Background(), matrixMessage{
+		MsgType:       "m.text",
+		Body:          title + "\n" + message,
+		Format:        "org.matrix.custom.html",
+		FormattedBody: "<strong>" + html.EscapeString(title) + "</strong><br>" + html.EscapeString(message),
+	})
+}
+
+// Send sends the event to the configured room as an HTML formatted
+// message, with a plain text fallback for clients that do not render HTML.
+func (m *MatrixNotifier) Send(ctx context.Context, event Event) error {
+	var text, formatted strings.Builder
+	text.WriteString(event.Title() + "\n" + event.Message() + "\n")
+	formatted.WriteString("<strong>" + html.EscapeString(event.Title()) + "</strong><br>" + matrixInlineCode(event.Message()) + "<ul>")
+	for _, f := range event.Fields() {
+		if f.Name == "Command" {
+			continue
+		}
+		fmt.Fprintf(&text, "%s: %s\n", f.Name, f.Value)
+		fmt.Fprintf(&formatted, "<li><strong>%s:</strong> %s</li>", html.EscapeString(f.Name), html.EscapeString(f.Value))
+	}
+	formatted.WriteString("</ul>")
+	if tail := event.OutputTail(0, matrixOutputBytes); tail != "" {
+		text.WriteString("\n" + tail + "\n")
+		formatted.WriteString("<pre><code>" + html.EscapeString(tail) + "</code></pre>")
+	}
+
+	msgType := "m.text"
+	if m.NoticeForSuccess && event.Success() {
+		msgType = "m.notice"
+	}
+	return m.send(ctx, matrixMessage{
+		MsgType:       msgType,
+		Body:          strings.TrimRight(text.String(), "\n"),
+		Format:        "org.matrix.custom.html",
+		FormattedBody: formatted.String(),
+	})
+}
+
+// matrixInlineCode escapes text for HTML, rendering spans in backticks,
+// such as the command line in an event message, as inline code.
+func matrixInlineCode(text string) string {
+	var b strings.Builder
+	for i, part := range strings.Split(text, "`") {
+		if i%2 == 1 {
+			b.WriteString("<code>" + html.EscapeString(part) + "</code>")
+		} else {
+			b.WriteString(html.EscapeString(part))
+		}
+	}
+	return b.String()
+}
+
+// send sends the message, retrying if the homeserver is rate limiting or
+// temporarily unavailable. Every attempt uses the same transaction ID, so
+// the homeserver ignores a retry of a message it already accepted.
+func (m *MatrixNotifier) send(ctx context.Context, message matrixMessage) error {
+	payloadBytes, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal matrix payload: %w", err)
+	}
+	txnID, err := newMatrixTxnID()
+	if err != nil {
+		return fmt.Errorf("failed to create matrix transaction ID: %w", err)
+	}
+	endpoint := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+		strings.TrimRight(m.HomeserverURL, "/"), url.PathEscape(m.RoomID), txnID)
+
+	for attempt := 1; ; attempt++ {
+		retryAfter, err := m.put(ctx, endpoint, payloadBytes)
+		if retryAfter == 0 || retryAfter > matrixMaxRetryAfter || attempt == matrixMaxAttempts {
+			return err
+		}
+
+		timer := time.NewTimer(retryAfter)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("failed to send matrix notification: %w", ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// put sends one attempt. If it failed in a way that is worth retrying, it
+// returns how long to wait before the next attempt along with the error.
+func (m *MatrixNotifier) put(ctx context.Context, endpoint string, payloadBytes []byte) (time.Duration, error) {
+	_, err := sendHTTP(ctx, http.MethodPut, endpoint, map[string]string{"Authorization": "Bearer " + m.AccessToken}, bytes.NewReader(payloadBytes))
+	if err == nil {
+		return 0, nil
+	}
+	var statusErr *httpStatusError
+	if !errors.As(err, &statusErr) {
+		if ctx.Err() != nil {
+			return 0, fmt.Errorf("failed to send matrix notification: %w", err)
+		}
+		return matrixRetryDelay, fmt.Errorf("failed to send matrix notification: %w", err)
+	}
+
+	var matrixErr matrixError
+	json.Unmarshal(statusErr.Body, &matrixErr)
+	err = fmt.Errorf("failed to send matrix notification: %w", err)
+	if matrixErr.ErrCode != "" {
+		err = fmt.Errorf("failed to send matrix notification: %s: %s", matrixErr.ErrCode, matrixErr.Error)
+	}
+
+	switch {
+	case statusErr.StatusCode == http.StatusTooManyRequests:
+		if matrixErr.RetryAfterMS > 0 {
+			return time.Duration(matrixErr.RetryAfterMS) * time.Millisecond, err
+		}
+		return matrixRetryDelay, err
+	case statusErr.StatusCode >= 500:
+		return matrixRetryDelay, err
+	default:
+		return 0, err
+	}
+}
+
+// newMatrixTxnID returns a random transaction ID.
+func newMatrixTxnID() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "nf-" + hex.EncodeToString(b), nil
+}
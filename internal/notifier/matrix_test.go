@@ -0,0 +1,133 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatrixNotifier_Send(t *testing.T) {
+	testCases := []struct {
+		name            string
+		event           Event
+		expectedType    string
+		expectedBody    string
+		expectedHTML    string
+		noticeForSucces bool
+	}{
+		{
+			name:         "failure",
+			event:        Event{Command: "cargo", Args: []string{"test", "<all>"}, Outcome: OutcomeFailed, ExitCode: 101, Duration: 90 * time.Second, Output: "test result: FAILED"},
+			expectedType: "m.text",
+			expectedBody: "Command Failed: cargo\nCommand `cargo test <all>` failed with exit code 101 after 90.00 seconds.\nStatus: failed with exit code 101\nExit Code: 101\nDuration: 1m30s\n\ntest result: FAILED",
+			expectedHTML: "<strong>Command Failed: cargo</strong><br>Command <code>cargo test &lt;all&gt;</code> failed with exit code 101 after 90.00 seconds." +
+				"<ul><li><strong>Status:</strong> failed with exit code 101</li><li><strong>Exit Code:</strong> 101</li><li><strong>Duration:</strong> 1m30s</li></ul>" +
+				"<pre><code>test result: FAILED</code></pre>",
+		},
+		{
+			name:            "success as notice",
+			event:           Event{Command: "cargo", Outcome: OutcomeSucceeded, Duration: time.Second},
+			expectedType:    "m.notice",
+			noticeForSucces: true,
+		},
+		{
+			name:         "success as text",
+			event:        Event{Command: "cargo", Outcome: OutcomeSucceeded, Duration: time.Second},
+			expectedType: "m.text",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "PUT", r.Method, "Expected PUT request")
+				assert.True(t, strings.HasPrefix(r.URL.EscapedPath(), "/_matrix/client/v3/rooms/%21room:example.org/send/m.room.message/nf-"), r.URL.EscapedPath())
+				assert.Equal(t, "Bearer syt_token", r.Header.Get("Authorization"))
+
+				bodyBytes, err := io.ReadAll(r.Body)
+				require.NoError(t, err, "Failed to read request body")
+
+				var payload matrixMessage
+				require.NoError(t, json.Unmarshal(bodyBytes, &payload), "Failed to unmarshal request body")
+				assert.Equal(t, tc.expectedType, payload.MsgType)
+				assert.Equal(t, "org.matrix.custom.html", payload.Format)
+				if tc.expectedBody != "" {
+					assert.Equal(t, tc.expectedBody, payload.Body)
+					assert.Equal(t, tc.expectedHTML, payload.FormattedBody)
+				}
+
+				io.WriteString(w, `{"event_id": "$abc"}`)
+			}))
+			defer server.Close()
+
+			n := NewMatrixNotifier(server.URL+"/", "syt_token", "!room:example.org")
+			n.NoticeForSuccess = tc.noticeForSucces
+			assert.NoError(t, n.Send(context.Background(), tc.event), "Send returned an unexpected error")
+		})
+	}
+}
+
+func TestMatrixNotifier_Retry(t *testing.T) {
+	originalDelay := matrixRetryDelay
+	matrixRetryDelay = time.Millisecond
+	defer func() { matrixRetryDelay = originalDelay }()
+
+	testCases := []struct {
+		name          string
+		responses     []int
+		expectedCalls int
+		expectedErr   string
+	}{
+		{name: "rate limited", responses: []int{http.StatusTooManyRequests, http.StatusOK}, expectedCalls: 2},
+		{name: "server error", responses: []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusOK}, expectedCalls: 3},
+		{name: "gives up", responses: []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusOK}, expectedCalls: matrixMaxAttempts, expectedErr: "received status code 502"},
+		{name: "forbidden is not retried", responses: []int{http.StatusForbidden, http.StatusOK}, expectedCalls: 1, expectedErr: "M_FORBIDDEN: not in room"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var paths []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				status := tc.responses[len(paths)]
+				paths = append(paths, r.URL.Path)
+				mu.Unlock()
+
+				w.WriteHeader(status)
+				switch status {
+				case http.StatusTooManyRequests:
+					io.WriteString(w, `{"errcode": "M_LIMIT_EXCEEDED", "error": "Too many requests", "retry_after_ms": 5}`)
+				case http.StatusForbidden:
+					io.WriteString(w, `{"errcode": "M_FORBIDDEN", "error": "not in room"}`)
+				case http.StatusOK:
+					io.WriteString(w, `{"event_id": "$abc"}`)
+				}
+			}))
+			defer server.Close()
+
+			err := NewMatrixNotifier(server.URL, "syt_token", "!room:example.org").Notify("Test Title", "Test Message")
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			require.Len(t, paths, tc.expectedCalls)
+			for _, path := range paths {
+				assert.Equal(t, paths[0], path, "Expected every attempt to reuse the transaction ID")
+			}
+		})
+	}
+}
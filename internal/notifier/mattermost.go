@@ -0,0 +1,99 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// MattermostNotifier sends notifications to a Mattermost incoming webhook.
+type MattermostNotifier struct {
+	WebhookURL string
+	// Channel overrides the webhook's default channel, e.g. "town-square"
+	// or "@username" for a direct message.
+	Channel string
+	// Username, IconURL and IconEmoji override how the post is signed. The
+	// server must allow integrations to override them.
+	Username  string
+	IconURL   string
+	IconEmoji string
+}
+
+// NewMattermostNotifier creates a new instance of MattermostNotifier.
+func NewMattermostNotifier(webhookURL string) *MattermostNotifier {
+	return &MattermostNotifier{WebhookURL: webhookURL}
+}
+
+// mattermostPayload is the JSON structure for a Mattermost webhook post.
+type mattermostPayload struct {
+	Text        string            `json:"text"`
+	Channel     string            `json:"channel,omitempty"`
+	Username    string            `json:"username,omitempty"`
+	IconURL     string            `json:"icon_url,omitempty"`
+	IconEmoji   string            `json:"icon_emoji,omitempty"`
+	Attachments []slackAttachment `json:"attachments,omitempty"`
+	Props       map[string]string `json:"props,omitempty"`
+}
+
+// mattermostCardBytes limits the captured output shown in the post's card.
+// Mattermost limits a post to 16383 characters including its props.
+const mattermostCardBytes = 12000
+
+// Notify sends a message to the configured Mattermost webhook.
+func (m *MattermostNotifier) Notify(title, message string) error {
+	return m.post(context.Background(), m.newPayload(fmt.Sprintf("**%s**\n%s", title, message)))
+}
+
+// Send sends the event to the configured Mattermost webhook, with the event
+// metadata rendered as attachment fields and the end of any captured output
+// as a code block. A longer tail of the output is shown in the post's card,
+// which opens in the sidebar.
+func (m *MattermostNotifier) Send(ctx context.Context, event Event) error {
+	text := fmt.Sprintf("**%s**\n%s", event.Title(), event.Message())
+	if tail := event.OutputTail(0, slackOutputBytes); tail != "" {
+		text += "\n" + slackCodeBlock(tail)
+	}
+	payload := m.newPayload(text)
+	payload.Attachments = []slackAttachment{newSlackAttachment(event, slackHexColor(event))}
+	if tail := event.OutputTail(0, mattermostCardBytes); tail != "" {
+		payload.Props = map[string]string{
+			"card": fmt.Sprintf("#### Output of `%s`\n%s", event.CommandLine(), slackCodeBlock(tail)),
+		}
+	}
+	return m.post(ctx, payload)
+}
+
+func (m *MattermostNotifier) newPayload(text string) mattermostPayload {
+	return mattermostPayload{
+		Text:      text,
+		Channel:   m.Channel,
+		Username:  m.Username,
+		IconURL:   m.IconURL,
+		IconEmoji: m.IconEmoji,
+	}
+}
+
+func (m *MattermostNotifier) post(ctx context.Context, payload mattermostPayload) error {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal mattermost payload: %w", err)
+	}
+
+	_, err = postJSON(ctx, m.WebhookURL, nil, payloadBytes)
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		// Mattermost explains errors, e.g. an unknown channel, in the body.
+		var body struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(statusErr.Body, &body)
+		if body.Message != "" {
+			return fmt.Errorf("failed to send mattermost notification: %w: %s", err, body.Message)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to send mattermost notification: %w", err)
+	}
+	return nil
+}
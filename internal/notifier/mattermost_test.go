@@ -0,0 +1,70 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMattermostNotifier_Send(t *testing.T) {
+	event := Event{
+		Command:  "make",
+		Args:     []string{"test"},
+		Outcome:  OutcomeFailed,
+		ExitCode: 2,
+		Duration: 90 * time.Second,
+		Output:   "FAIL: TestParse",
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method, "Expected POST request")
+
+		bodyBytes, err := io.ReadAll(r.Body)
+		require.NoError(t, err, "Failed to read request body")
+
+		var payload mattermostPayload
+		require.NoError(t, json.Unmarshal(bodyBytes, &payload), "Failed to unmarshal request body")
+
+		assert.Equal(t, "**Command Failed: make**\nCommand `make test` failed with exit code 2 after 90.00 seconds.\n```\nFAIL: TestParse\n```", payload.Text)
+		assert.Equal(t, "builds", payload.Channel)
+		assert.Equal(t, "nf", payload.Username)
+		assert.Equal(t, ":robot:", payload.IconEmoji)
+		require.Len(t, payload.Attachments, 1)
+		assert.Equal(t, "#E01E5A", payload.Attachments[0].Color)
+		assert.Equal(t, "Command Failed: make", payload.Attachments[0].Fallback)
+		assert.Contains(t, payload.Attachments[0].Fields, slackField{Title: "Exit Code", Value: "2", Short: true})
+		assert.Equal(t, "#### Output of `make test`\n```\nFAIL: TestParse\n```", payload.Props["card"])
+
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	n := NewMattermostNotifier(server.URL)
+	n.Channel, n.Username, n.IconEmoji = "builds", "nf", ":robot:"
+	assert.NoError(t, n.Send(context.Background(), event), "Send returned an unexpected error")
+}
+
+func TestMattermostNotifier_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"id": "web.incoming_webhook.channel.app_error", "message": "Couldn't find the channel.", "status_code": 400}`))
+	}))
+	defer server.Close()
+
+	err := NewMattermostNotifier(server.URL).Notify("Test Title", "Test Message")
+	assert.EqualError(t, err, "failed to send mattermost notification: received status code 400: Couldn't find the channel.")
+
+	// The hook ID in the URL is the secret, so it must not appear in errors.
+	closed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	closed.Close()
+	err = NewMattermostNotifier(closed.URL+"/hooks/secret-hook-id").Notify("Test Title", "Test Message")
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret-hook-id")
+}
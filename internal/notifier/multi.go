@@ -0,0 +1,107 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// MultiNotifier sends each event to several notifiers concurrently, so that
+// a slow or failing backend does not hold up or prevent the others.
+type MultiNotifier struct {
+	names     []string
+	notifiers []Notifier
+}
+
+// NewMultiNotifier creates a new, empty instance of MultiNotifier.
+func NewMultiNotifier() *MultiNotifier {
+	return &MultiNotifier{}
+}
+
+// Add registers a notifier under the given name. The name is used when
+// reporting results.
+func (m *MultiNotifier) Add(name string, n Notifier) {
+	m.names = append(m.names, name)
+	m.notifiers = append(m.notifiers, n)
+}
+
+// Result is the outcome of sending an event to a single named notifier.
+type Result struct {
+	Name string
+	Err  error
+}
+
+// SendAll sends the event to every registered notifier concurrently and
+// waits for all of them to finish, or for ctx to be done. Results are
+// returned in the order the notifiers were added.
+func (m *MultiNotifier) SendAll(ctx context.Context, event Event) []Result {
+	results := make([]Result, len(m.notifiers))
+
+	var wg sync.WaitGroup
+	for i, n := range m.notifiers {
+		wg.Add(1)
+		go func(i int, n Notifier) {
+			defer wg.Done()
+			results[i] = Result{Name: m.names[i], Err: SendContext(ctx, n, event)}
+		}(i, n)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// SendContext sends the event and waits until the notifier returns or ctx
+// is done. Not every notifier watches ctx, so one that hangs is abandoned
+// and the cause of ctx being done, e.g. its deadline, is returned.
+func SendContext(ctx context.Context, n Notifier, event Event) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- n.Send(ctx, event)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// Send sends the event to every registered notifier. If any of them fail,
+// a *SendError describing every failure is returned.
+func (m *MultiNotifier) Send(ctx context.Context, event Event) error {
+	results := m.SendAll(ctx, event)
+	for _, r := range results {
+		if r.Err != nil {
+			return &SendError{Results: results}
+		}
+	}
+	return nil
+}
+
+// SendError is returned by MultiNotifier.Send when at least one notifier
+// failed. It holds the results of all notifiers, including the successful ones.
+type SendError struct {
+	Results []Result
+}
+
+func (e *SendError) Error() string {
+	var failures []string
+	for _, r := range e.Results {
+		if r.Err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", r.Name, r.Err))
+		}
+	}
+	return fmt.Sprintf("%d of %d notifiers failed: %s", len(failures), len(e.Results), strings.Join(failures, "; "))
+}
+
+// Unwrap returns the individual notifier errors.
+func (e *SendError) Unwrap() []error {
+	var errs []error
+	for _, r := range e.Results {
+		if r.Err != nil {
+			errs = append(errs, r.Err)
+		}
+	}
+	return errs
+}
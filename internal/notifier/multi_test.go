@@ -0,0 +1,87 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// funcNotifier is a Notifier backed by a function, for testing.
+type funcNotifier func(ctx context.Context, event Event) error
+
+func (f funcNotifier) Send(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+func TestMultiNotifier_Send(t *testing.T) {
+	errBoom := errors.New("boom")
+	var received []string
+
+	multi := NewMultiNotifier()
+	multi.Add("ok", funcNotifier(func(ctx context.Context, event Event) error {
+		received = append(received, event.Command)
+		return nil
+	}))
+	multi.Add("broken", funcNotifier(func(ctx context.Context, event Event) error {
+		return errBoom
+	}))
+
+	err := multi.Send(context.Background(), Event{Command: "make"})
+	require.Error(t, err)
+
+	var sendErr *SendError
+	require.ErrorAs(t, err, &sendErr)
+	assert.Equal(t, []Result{{Name: "ok"}, {Name: "broken", Err: errBoom}}, sendErr.Results)
+	assert.ErrorIs(t, err, errBoom)
+	assert.Equal(t, "1 of 2 notifiers failed: broken: boom", err.Error())
+	assert.Equal(t, []string{"make"}, received, "Expected the healthy notifier to still receive the event")
+}
+
+func TestMultiNotifier_SendsConcurrently(t *testing.T) {
+	// The first notifier blocks until the second one has been called, which
+	// can only happen if they are dispatched concurrently.
+	secondCalled := make(chan struct{})
+
+	multi := NewMultiNotifier()
+	multi.Add("first", funcNotifier(func(ctx context.Context, event Event) error {
+		select {
+		case <-secondCalled:
+			return nil
+		case <-time.After(5 * time.Second):
+			return errors.New("second notifier was not called concurrently")
+		}
+	}))
+	multi.Add("second", funcNotifier(func(ctx context.Context, event Event) error {
+		close(secondCalled)
+		return nil
+	}))
+
+	assert.NoError(t, multi.Send(context.Background(), Event{}))
+}
+
+func TestMultiNotifier_SendAllGivesUpOnHangingNotifier(t *testing.T) {
+	errTimeout := errors.New("timed out after 50ms")
+	ctx, cancel := context.WithTimeoutCause(context.Background(), 50*time.Millisecond, errTimeout)
+	defer cancel()
+
+	// The hanging notifier ignores ctx, like a notifier shelling out without
+	// a deadline would.
+	hang := make(chan struct{})
+	defer close(hang)
+
+	multi := NewMultiNotifier()
+	multi.Add("ok", funcNotifier(func(ctx context.Context, event Event) error {
+		return nil
+	}))
+	multi.Add("hanging", funcNotifier(func(ctx context.Context, event Event) error {
+		<-hang
+		return nil
+	}))
+
+	results := multi.SendAll(ctx, Event{})
+	assert.Equal(t, []Result{{Name: "ok"}, {Name: "hanging", Err: errTimeout}}, results)
+}
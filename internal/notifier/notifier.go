@@ -1,40 +1,32 @@
 package notifier
 
-import (
-	"fmt"
-	"github.com/jules-labs/nf/internal/cmd"
-)
+import "context"
 
 // Notifier is the interface for sending notifications.
 type Notifier interface {
+	Send(ctx context.Context, event Event) error
+}
+
+// TitleMessageNotifier is implemented by notifiers that only accept a
+// pre-rendered title and message. Use FromTitleMessage to turn one into a
+// Notifier.
+type TitleMessageNotifier interface {
 	Notify(title, message string) error
 }
 
-// GetNotifier returns the appropriate notifier based on the configuration.
-func GetNotifier(config cmd.Config) (Notifier, error) {
-	switch config.Notifier {
-	case "os":
-		return &OSNotifier{}, nil
-	case "slack":
-		if config.SlackWebhook == "" {
-			return nil, fmt.Errorf("slack notifier selected but no webhook URL provided (set NF_SLACK_WEBHOOK)")
-		}
-		return NewSlackNotifier(config.SlackWebhook), nil
-	case "teams":
-		if config.TeamsWebhook == "" {
-			return nil, fmt.Errorf("teams notifier selected but no webhook URL provided (set NF_TEAMS_WEBHOOK)")
-		}
-		return NewTeamsNotifier(config.TeamsWebhook), nil
-	case "app":
-		if config.APIURL == "" {
-			return nil, fmt.Errorf("app notifier selected but no API URL provided (set NF_API_URL)")
-		}
-		return NewAppNotifier(config.APIURL, config.APIToken), nil
-	case "none", "": // Also allow disabling notifications explicitly
-		return &NoOpNotifier{}, nil
-	default:
-		return nil, fmt.Errorf("unknown notifier: %s", config.Notifier)
-	}
+// FromTitleMessage adapts a TitleMessageNotifier to the Notifier interface by
+// rendering the event with Event.Title and Event.Message.
+func FromTitleMessage(n TitleMessageNotifier) Notifier {
+	return titleMessageAdapter{n}
+}
+
+type titleMessageAdapter struct {
+	TitleMessageNotifier
+}
+
+// Send renders the event as a title and message and passes them to Notify.
+func (a titleMessageAdapter) Send(ctx context.Context, event Event) error {
+	return a.Notify(event.Title(), event.Message())
 }
 
 // NoOpNotifier is a notifier that does nothing.
@@ -44,3 +36,8 @@ type NoOpNotifier struct{}
 func (n *NoOpNotifier) Notify(title, message string) error {
 	return nil
 }
+
+// Send does nothing and returns nil.
+func (n *NoOpNotifier) Send(ctx context.Context, event Event) error {
+	return nil
+}
@@ -0,0 +1,127 @@
+package notifier
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DefaultNtfyServer is the public ntfy server used unless
+// NtfyNotifier.ServerURL is set.
+const DefaultNtfyServer = "https://ntfy.sh"
+
+// NtfyNotifier publishes notifications to an ntfy topic, which the ntfy
+// apps deliver as push notifications.
+type NtfyNotifier struct {
+	ServerURL string
+	Topic     string
+
+	// Token is an access token. Username and Password are used for basic
+	// authentication instead if no token is set.
+	Token    string
+	Username string
+	Password string
+
+	// Click is a URL opened when the notification is tapped, and Actions
+	// action buttons in ntfy's short header format, e.g.
+	// "view, Open CI, https://ci.example.com".
+	Click   string
+	Actions string
+}
+
+// NewNtfyNotifier creates a new instance of NtfyNotifier for the topic on
+// the given server, or on ntfy.sh if serverURL is empty.
+func NewNtfyNotifier(serverURL, topic string) *NtfyNotifier {
+	if serverURL == "" {
+		serverURL = DefaultNtfyServer
+	}
+	return &NtfyNotifier{ServerURL: serverURL, Topic: topic}
+}
+
+// ntfy message priorities.
+const (
+	ntfyPriorityDefault = 3
+	ntfyPriorityHigh    = 4
+)
+
+// ntfyOutputBytes limits the captured output included in a message.
+const ntfyOutputBytes = 2000
+
+// Notify publishes a message to the configured topic.
+func (n *NtfyNotifier) Notify(title, message string) error {
+	return n.publish(context.Background(), title, message, ntfyPriorityDefault, nil)
+}
+
+// Send publishes the event to the configured topic. The outcome sets the
+// priority and an emoji tag, and any captured output is appended to the
+// message.
+func (n *NtfyNotifier) Send(ctx context.Context, event Event) error {
+	message := event.Message()
+	if tail := event.OutputTail(0, ntfyOutputBytes); tail != "" {
+		message += "\n\n" + tail
+	}
+	priority, tag := ntfyPriorityAndTag(event)
+	tags := []string{tag}
+	if event.Host != "" {
+		tags = append(tags, event.Host)
+	}
+	return n.publish(ctx, event.Title(), message, priority, tags)
+}
+
+// ntfyPriorityAndTag maps the event's outcome onto an ntfy priority and a
+// tag, which ntfy shows as an emoji if it is an emoji short code.
+func ntfyPriorityAndTag(event Event) (int, string) {
+	switch event.Outcome {
+	case OutcomeSucceeded:
+		return ntfyPriorityDefault, "white_check_mark"
+	case OutcomeFailed:
+		return ntfyPriorityHigh, "x"
+	case OutcomeKilled:
+		return ntfyPriorityHigh, "skull"
+	case OutcomeTimedOut:
+		return ntfyPriorityHigh, "hourglass"
+	case OutcomeInterrupted:
+		return ntfyPriorityDefault, "warning"
+	case OutcomeRunning:
+		return ntfyPriorityDefault, "hourglass_flowing_sand"
+	case OutcomeMatched:
+		return ntfyPriorityDefault, "mag"
+	default:
+		return ntfyPriorityDefault, "bell"
+	}
+}
+
+func (n *NtfyNotifier) publish(ctx context.Context, title, message string, priority int, tags []string) error {
+	url := strings.TrimRight(n.ServerURL, "/") + "/" + n.Topic
+	headers := map[string]string{
+		"Content-Type": "text/plain; charset=utf-8",
+		// Header values must be ASCII, so the title is MIME-encoded if it
+		// contains anything else, which ntfy decodes.
+		"Title":    mime.QEncoding.Encode("utf-8", title),
+		"Priority": strconv.Itoa(priority),
+	}
+	if len(tags) > 0 {
+		headers["Tags"] = strings.Join(tags, ",")
+	}
+	if n.Click != "" {
+		headers["Click"] = n.Click
+	}
+	if n.Actions != "" {
+		headers["Actions"] = n.Actions
+	}
+	switch {
+	case n.Token != "":
+		headers["Authorization"] = "Bearer " + n.Token
+	case n.Username != "":
+		headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(n.Username+":"+n.Password))
+	}
+
+	if _, err := sendHTTP(ctx, http.MethodPost, url, headers, strings.NewReader(message)); err != nil {
+		return fmt.Errorf("failed to send ntfy notification: %w", err)
+	}
+	return nil
+}
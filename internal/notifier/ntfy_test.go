@@ -0,0 +1,109 @@
+package notifier
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNtfyNotifier_Send(t *testing.T) {
+	testCases := []struct {
+		name             string
+		event            Event
+		expectedTitle    string
+		expectedBody     string
+		expectedPriority string
+		expectedTags     string
+	}{
+		{
+			name:             "failure",
+			event:            Event{Command: "make", Args: []string{"test"}, Outcome: OutcomeFailed, ExitCode: 2, Duration: 90 * time.Second, Host: "buildbox", Output: "FAIL: TestParse"},
+			expectedTitle:    "Command Failed: make",
+			expectedBody:     "Command `make test` failed with exit code 2 after 90.00 seconds.\n\nFAIL: TestParse",
+			expectedPriority: "4",
+			expectedTags:     "x,buildbox",
+		},
+		{
+			name:             "success",
+			event:            Event{Command: "make", Outcome: OutcomeSucceeded, Duration: 90 * time.Second},
+			expectedTitle:    "Command Succeeded: make",
+			expectedBody:     "Command `make` succeeded in 90.00 seconds.",
+			expectedPriority: "3",
+			expectedTags:     "white_check_mark",
+		},
+		{
+			name:             "non-ASCII title",
+			event:            Event{Command: "ビルド", Outcome: OutcomeTimedOut, Timeout: time.Hour},
+			expectedTitle:    "=?utf-8?q?Command_Timed_Out:_=E3=83=93=E3=83=AB=E3=83=89?=",
+			expectedBody:     "Command `ビルド` timed out after 1h0m0s.",
+			expectedPriority: "4",
+			expectedTags:     "hourglass",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "POST", r.Method, "Expected POST request")
+				assert.Equal(t, "/builds", r.URL.Path)
+				assert.Equal(t, tc.expectedTitle, r.Header.Get("Title"))
+				assert.Equal(t, tc.expectedPriority, r.Header.Get("Priority"))
+				assert.Equal(t, tc.expectedTags, r.Header.Get("Tags"))
+				assert.Equal(t, "https://ci.example.com", r.Header.Get("Click"))
+				assert.Equal(t, "view, Open CI, https://ci.example.com", r.Header.Get("Actions"))
+
+				bodyBytes, err := io.ReadAll(r.Body)
+				require.NoError(t, err, "Failed to read request body")
+				assert.Equal(t, tc.expectedBody, string(bodyBytes))
+
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			n := NewNtfyNotifier(server.URL+"/", "builds")
+			n.Click = "https://ci.example.com"
+			n.Actions = "view, Open CI, https://ci.example.com"
+
+			assert.NoError(t, n.Send(context.Background(), tc.event), "Send returned an unexpected error")
+		})
+	}
+}
+
+func TestNtfyNotifier_Auth(t *testing.T) {
+	testCases := []struct {
+		name         string
+		token        string
+		username     string
+		password     string
+		expectedAuth string
+	}{
+		{name: "no auth"},
+		{name: "token", token: "tk_secret", expectedAuth: "Bearer tk_secret"},
+		{name: "basic", username: "phil", password: "pass", expectedAuth: "Basic cGhpbDpwYXNz"},
+		{name: "token wins over basic", token: "tk_secret", username: "phil", password: "pass", expectedAuth: "Bearer tk_secret"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tc.expectedAuth, r.Header.Get("Authorization"))
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			n := NewNtfyNotifier(server.URL, "builds")
+			n.Token, n.Username, n.Password = tc.token, tc.username, tc.password
+			assert.NoError(t, n.Notify("Test Title", "Test Message"))
+		})
+	}
+}
+
+func TestNewNtfyNotifier_DefaultServer(t *testing.T) {
+	assert.Equal(t, DefaultNtfyServer, NewNtfyNotifier("", "builds").ServerURL)
+}
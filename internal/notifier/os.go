@@ -1,6 +1,10 @@
 package notifier
 
-import "github.com/gen2brain/beeep"
+import (
+	"context"
+
+	"github.com/gen2brain/beeep"
+)
 
 // OSNotifier sends notifications using the OS's native notification system.
 type OSNotifier struct{}
@@ -11,3 +15,21 @@ func (n *OSNotifier) Notify(title, message string) error {
 	// For more advanced usage, you could specify an icon path.
 	return beeep.Notify(title, message, "")
 }
+
+// osOutputLines and osOutputBytes limit the output shown in a popup, which
+// most desktops truncate after a few lines anyway.
+const (
+	osOutputLines = 3
+	osOutputBytes = 200
+)
+
+// Send sends a desktop notification for the event. Desktop popups have no
+// room for structured fields, so only the title and message are shown,
+// followed by the last few lines of captured output.
+func (n *OSNotifier) Send(ctx context.Context, event Event) error {
+	message := event.Message()
+	if tail := event.OutputTail(osOutputLines, osOutputBytes); tail != "" {
+		message += "\n" + tail
+	}
+	return n.Notify(event.Title(), message)
+}
@@ -0,0 +1,185 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPushoverAPIURL is the Pushover API used unless
+// PushoverNotifier.BaseURL is changed.
+const DefaultPushoverAPIURL = "https://api.pushover.net"
+
+// Pushover message priorities.
+const (
+	pushoverPriorityLow       = -1
+	pushoverPriorityNormal    = 0
+	pushoverPriorityHigh      = 1
+	pushoverPriorityEmergency = 2
+)
+
+// Limits Pushover places on emergency notifications, and the defaults nf
+// uses for them.
+const (
+	PushoverMinRetry      = 30 * time.Second
+	PushoverMaxExpire     = 3 * time.Hour
+	PushoverDefaultRetry  = time.Minute
+	PushoverDefaultExpire = time.Hour
+)
+
+// PushoverNotifier sends notifications through Pushover.
+type PushoverNotifier struct {
+	BaseURL string
+	// Token is the application's API token and User the user or group key
+	// to notify.
+	Token string
+	User  string
+	// Device limits delivery to the named devices, comma-separated.
+	Device string
+
+	// URL and URLTitle add a link to the notification.
+	URL      string
+	URLTitle string
+
+	// Sounds maps outcomes to the Pushover sound to play for them. Outcomes
+	// without a sound use the user's default.
+	Sounds map[Outcome]string
+
+	// Emergency lists the outcomes sent with emergency priority, which
+	// repeats the notification every Retry until it is acknowledged or
+	// Expire has passed.
+	Emergency []Outcome
+	Retry     time.Duration
+	Expire    time.Duration
+}
+
+// NewPushoverNotifier creates a new instance of PushoverNotifier.
+func NewPushoverNotifier(token, user string) *PushoverNotifier {
+	return &PushoverNotifier{
+		BaseURL: DefaultPushoverAPIURL,
+		Token:   token,
+		User:    user,
+		Retry:   PushoverDefaultRetry,
+		Expire:  PushoverDefaultExpire,
+	}
+}
+
+// pushoverResponse is the JSON structure of a Pushover API response.
+type pushoverResponse struct {
+	Status int      `json:"status"`
+	Errors []string `json:"errors"`
+}
+
+// Limits Pushover places on titles and messages, and how much of the
+// captured output is included in a message. Pushover counts characters, so
+// limiting bytes stays within them.
+const (
+	pushoverTitleLimit   = 250
+	pushoverMessageLimit = 1024
+	pushoverOutputBytes  = 600
+)
+
+// Notify sends a message through Pushover.
+func (p *PushoverNotifier) Notify(title, message string) error {
+	return p.post(context.Background(), p.newForm(title, message))
+}
+
+// Send sends the event through Pushover, with a priority and sound chosen
+// by its outcome.
+func (p *PushoverNotifier) Send(ctx context.Context, event Event) error {
+	message := event.Message()
+	if tail := event.OutputTail(0, pushoverOutputBytes); tail != "" {
+		message += "\n\n" + tail
+	}
+
+	form := p.newForm(event.Title(), message)
+	priority := p.priority(event)
+	form.Set("priority", strconv.Itoa(priority))
+	if priority == pushoverPriorityEmergency {
+		form.Set("retry", strconv.Itoa(int(p.Retry.Seconds())))
+		form.Set("expire", strconv.Itoa(int(p.Expire.Seconds())))
+	}
+	if sound := p.Sounds[event.Outcome]; sound != "" {
+		form.Set("sound", sound)
+	}
+	if !event.EndTime.IsZero() {
+		form.Set("timestamp", strconv.FormatInt(event.EndTime.Unix(), 10))
+	}
+	return p.post(ctx, form)
+}
+
+func (p *PushoverNotifier) newForm(title, message string) url.Values {
+	form := url.Values{}
+	form.Set("token", p.Token)
+	form.Set("user", p.User)
+	form.Set("title", pushoverTruncate(title, pushoverTitleLimit))
+	form.Set("message", pushoverTruncate(message, pushoverMessageLimit))
+	if p.Device != "" {
+		form.Set("device", p.Device)
+	}
+	if p.URL != "" {
+		form.Set("url", p.URL)
+		if p.URLTitle != "" {
+			form.Set("url_title", p.URLTitle)
+		}
+	}
+	return form
+}
+
+// pushoverTruncate shortens s to at most limit bytes, since Pushover
+// rejects longer titles and messages instead of cutting them.
+func pushoverTruncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	return truncateUTF8(s, limit-3) + "..."
+}
+
+// priority returns the Pushover priority for the event's outcome.
+// Successes are sent quietly and failures with high priority, unless the
+// outcome is configured to be an emergency.
+func (p *PushoverNotifier) priority(event Event) int {
+	for _, outcome := range p.Emergency {
+		if outcome == event.Outcome {
+			return pushoverPriorityEmergency
+		}
+	}
+	switch event.Outcome {
+	case OutcomeSucceeded:
+		return pushoverPriorityLow
+	case OutcomeFailed, OutcomeKilled, OutcomeTimedOut:
+		return pushoverPriorityHigh
+	default:
+		return pushoverPriorityNormal
+	}
+}
+
+func (p *PushoverNotifier) post(ctx context.Context, form url.Values) error {
+	endpoint := strings.TrimRight(p.BaseURL, "/") + "/1/messages.json"
+	data, err := sendHTTP(ctx, http.MethodPost, endpoint, map[string]string{"Content-Type": "application/x-www-form-urlencoded"}, strings.NewReader(form.Encode()))
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		data = statusErr.Body
+	} else if err != nil {
+		return fmt.Errorf("failed to send pushover notification: %w", err)
+	}
+
+	var result pushoverResponse
+	json.Unmarshal(data, &result)
+	if statusErr != nil || result.Status != 1 {
+		if len(result.Errors) > 0 {
+			return fmt.Errorf("failed to send pushover notification: %s", strings.Join(result.Errors, "; "))
+		}
+		if statusErr != nil {
+			return fmt.Errorf("failed to send pushover notification: %w", err)
+		}
+		return fmt.Errorf("failed to send pushover notification: invalid response")
+	}
+	return nil
+}
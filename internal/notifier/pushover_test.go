@@ -0,0 +1,137 @@
+package notifier
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPushoverNotifier_Send(t *testing.T) {
+	end := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		event    Event
+		expected url.Values
+		absent   []string
+	}{
+		{
+			name:  "failure",
+			event: Event{Command: "make", Outcome: OutcomeFailed, ExitCode: 2, Duration: 90 * time.Second, EndTime: end, Output: "FAIL"},
+			expected: url.Values{
+				"title":     {"Command Failed: make"},
+				"message":   {"Command `make` failed with exit code 2 after 90.00 seconds.\n\nFAIL"},
+				"priority":  {"1"},
+				"sound":     {"siren"},
+				"timestamp": {"1714564800"},
+			},
+			absent: []string{"retry", "expire"},
+		},
+		{
+			name:  "success",
+			event: Event{Command: "make", Outcome: OutcomeSucceeded, Duration: 90 * time.Second},
+			expected: url.Values{
+				"priority": {"-1"},
+				"sound":    {"magic"},
+			},
+			absent: []string{"retry", "expire", "timestamp"},
+		},
+		{
+			name:  "emergency",
+			event: Event{Command: "deploy", Outcome: OutcomeTimedOut, Timeout: time.Hour},
+			expected: url.Values{
+				"priority": {"2"},
+				"retry":    {"60"},
+				"expire":   {"3600"},
+			},
+			absent: []string{"sound"},
+		},
+		{
+			name:     "interrupted",
+			event:    Event{Command: "make", Outcome: OutcomeInterrupted, Signal: "SIGINT"},
+			expected: url.Values{"priority": {"0"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "POST", r.Method, "Expected POST request")
+				assert.Equal(t, "/1/messages.json", r.URL.Path)
+				require.NoError(t, r.ParseForm())
+
+				assert.Equal(t, "app-token", r.PostForm.Get("token"))
+				assert.Equal(t, "user-key", r.PostForm.Get("user"))
+				assert.Equal(t, "phone", r.PostForm.Get("device"))
+				assert.Equal(t, "https://ci.example.com", r.PostForm.Get("url"))
+				assert.Equal(t, "Open CI", r.PostForm.Get("url_title"))
+				for key, value := range tc.expected {
+					assert.Equal(t, value, r.PostForm[key], "Unexpected %s", key)
+				}
+				for _, key := range tc.absent {
+					assert.NotContains(t, r.PostForm, key)
+				}
+
+				io.WriteString(w, `{"status": 1, "request": "abc"}`)
+			}))
+			defer server.Close()
+
+			n := NewPushoverNotifier("app-token", "user-key")
+			n.BaseURL = server.URL
+			n.Device = "phone"
+			n.URL, n.URLTitle = "https://ci.example.com", "Open CI"
+			n.Sounds = map[Outcome]string{OutcomeFailed: "siren", OutcomeSucceeded: "magic"}
+			n.Emergency = []Outcome{OutcomeTimedOut}
+
+			assert.NoError(t, n.Send(context.Background(), tc.event), "Send returned an unexpected error")
+		})
+	}
+}
+
+func TestPushoverNotifier_LongCommandLine(t *testing.T) {
+	var form url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		form = r.PostForm
+		io.WriteString(w, `{"status": 1, "request": "abc"}`)
+	}))
+	defer server.Close()
+
+	n := NewPushoverNotifier("app-token", "user-key")
+	n.BaseURL = server.URL
+
+	args := strings.Fields(strings.Repeat("--größe ", 150))
+	event := Event{Command: "convert", Args: args, Outcome: OutcomeFailed, ExitCode: 1, Output: strings.Repeat("x", 500)}
+	require.NoError(t, n.Send(context.Background(), event))
+	message := form.Get("message")
+	assert.LessOrEqual(t, len(message), pushoverMessageLimit)
+	assert.True(t, utf8.ValidString(message), "Expected the message to be valid UTF-8")
+	assert.True(t, strings.HasSuffix(message, "..."), "Expected the message to be truncated")
+
+	require.NoError(t, n.Notify(strings.Repeat("ä", 200), "message"))
+	title := form.Get("title")
+	assert.LessOrEqual(t, len(title), pushoverTitleLimit)
+	assert.True(t, utf8.ValidString(title), "Expected the title to be valid UTF-8")
+}
+
+func TestPushoverNotifier_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, `{"user": "invalid", "errors": ["user identifier is invalid"], "status": 0, "request": "abc"}`)
+	}))
+	defer server.Close()
+
+	n := NewPushoverNotifier("app-token", "bad")
+	n.BaseURL = server.URL
+	err := n.Notify("Test Title", "Test Message")
+	assert.EqualError(t, err, "failed to send pushover notification: user identifier is invalid")
+}
@@ -0,0 +1,97 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// RocketChatNotifier sends notifications to a Rocket.Chat incoming webhook.
+type RocketChatNotifier struct {
+	WebhookURL string
+	// Channel overrides the webhook's default channel, e.g. "#builds" or
+	// "@username" for a direct message.
+	Channel string
+	// Alias is the name shown instead of the webhook user's, and Emoji
+	// (e.g. ":robot:") or Avatar (an image URL) its picture.
+	Alias  string
+	Emoji  string
+	Avatar string
+}
+
+// NewRocketChatNotifier creates a new instance of RocketChatNotifier.
+func NewRocketChatNotifier(webhookURL string) *RocketChatNotifier {
+	return &RocketChatNotifier{WebhookURL: webhookURL}
+}
+
+// rocketChatPayload is the JSON structure for a Rocket.Chat webhook message.
+type rocketChatPayload struct {
+	Text        string            `json:"text"`
+	Channel     string            `json:"channel,omitempty"`
+	Alias       string            `json:"alias,omitempty"`
+	Emoji       string            `json:"emoji,omitempty"`
+	Avatar      string            `json:"avatar,omitempty"`
+	Attachments []slackAttachment `json:"attachments,omitempty"`
+}
+
+// Notify sends a message to the configured Rocket.Chat webhook.
+func (r *RocketChatNotifier) Notify(title, message string) error {
+	return r.post(context.Background(), r.newPayload(fmt.Sprintf("*%s*\n%s", title, message)))
+}
+
+// Send sends the event to the configured Rocket.Chat webhook, with the
+// event metadata rendered as attachment fields and any captured output as a
+// code block.
+func (r *RocketChatNotifier) Send(ctx context.Context, event Event) error {
+	text := fmt.Sprintf("*%s*\n%s", event.Title(), event.Message())
+	if tail := event.OutputTail(0, slackOutputBytes); tail != "" {
+		text += "\n" + slackCodeBlock(tail)
+	}
+	payload := r.newPayload(text)
+	payload.Attachments = []slackAttachment{newSlackAttachment(event, slackHexColor(event))}
+	return r.post(ctx, payload)
+}
+
+func (r *RocketChatNotifier) newPayload(text string) rocketChatPayload {
+	return rocketChatPayload{
+		Text:    text,
+		Channel: r.Channel,
+		Alias:   r.Alias,
+		Emoji:   r.Emoji,
+		Avatar:  r.Avatar,
+	}
+}
+
+func (r *RocketChatNotifier) post(ctx context.Context, payload rocketChatPayload) error {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal rocketchat payload: %w", err)
+	}
+
+	data, err := postJSON(ctx, r.WebhookURL, nil, payloadBytes)
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		data = statusErr.Body
+	} else if err != nil {
+		return fmt.Errorf("failed to send rocketchat notification: %w", err)
+	}
+
+	// Rocket.Chat answers with {"success": false, "error": "..."} if the
+	// message was rejected, e.g. because the channel does not exist,
+	// sometimes with a 200 status.
+	var body struct {
+		Success *bool  `json:"success"`
+		Error   string `json:"error"`
+	}
+	json.Unmarshal(data, &body)
+	switch {
+	case statusErr != nil && body.Error != "":
+		return fmt.Errorf("failed to send rocketchat notification: %w: %s", err, body.Error)
+	case statusErr != nil:
+		return fmt.Errorf("failed to send rocketchat notification: %w", err)
+	case body.Success != nil && !*body.Success:
+		return fmt.Errorf("failed to send rocketchat notification: %s", body.Error)
+	}
+	return nil
+}
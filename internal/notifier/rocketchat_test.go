@@ -0,0 +1,83 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRocketChatNotifier_Send(t *testing.T) {
+	event := Event{
+		Command:  "make",
+		Args:     []string{"test"},
+		Outcome:  OutcomeSucceeded,
+		Duration: 90 * time.Second,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method, "Expected POST request")
+
+		bodyBytes, err := io.ReadAll(r.Body)
+		require.NoError(t, err, "Failed to read request body")
+
+		var payload rocketChatPayload
+		require.NoError(t, json.Unmarshal(bodyBytes, &payload), "Failed to unmarshal request body")
+
+		assert.Equal(t, "*Command Succeeded: make*\nCommand `make test` succeeded in 90.00 seconds.", payload.Text)
+		assert.Equal(t, "#builds", payload.Channel)
+		assert.Equal(t, "nf", payload.Alias)
+		assert.Equal(t, ":robot:", payload.Emoji)
+		require.Len(t, payload.Attachments, 1)
+		assert.Equal(t, "#2EB67D", payload.Attachments[0].Color)
+		assert.Contains(t, payload.Attachments[0].Fields, slackField{Title: "Command", Value: "make test", Short: false})
+
+		w.Write([]byte(`{"success": true}`))
+	}))
+	defer server.Close()
+
+	n := NewRocketChatNotifier(server.URL)
+	n.Channel, n.Alias, n.Emoji = "#builds", "nf", ":robot:"
+	assert.NoError(t, n.Send(context.Background(), event), "Send returned an unexpected error")
+}
+
+func TestRocketChatNotifier_Error(t *testing.T) {
+	testCases := []struct {
+		name     string
+		status   int
+		body     string
+		expected string
+	}{
+		{name: "rejected", status: http.StatusBadRequest, body: `{"success": false, "error": "invalid-channel"}`, expected: "received status code 400: invalid-channel"},
+		{name: "rejected with ok status", status: http.StatusOK, body: `{"success": false, "error": "invalid-channel"}`, expected: "failed to send rocketchat notification: invalid-channel"},
+		{name: "no body", status: http.StatusNotFound, expected: "received status code 404"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+
+			err := NewRocketChatNotifier(server.URL).Notify("Test Title", "Test Message")
+			assert.ErrorContains(t, err, tc.expected)
+		})
+	}
+
+	t.Run("connection error hides the webhook URL", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		server.Close()
+
+		err := NewRocketChatNotifier(server.URL+"/hooks/id/secret-token").Notify("Test Title", "Test Message")
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), "secret-token")
+	})
+}
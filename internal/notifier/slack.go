@@ -1,47 +1,326 @@
 package notifier
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
+	"strings"
+	"sync"
 )
 
-// SlackNotifier sends notifications to a Slack webhook.
+// DefaultSlackAPIURL is the base URL of the Slack Web API.
+const DefaultSlackAPIURL = "https://slack.com/api"
+
+// SlackNotifier sends notifications to Slack, either to an incoming webhook
+// or, if Token is set, to any channel through the Web API.
 type SlackNotifier struct {
 	WebhookURL string
+
+	// Token is a bot token ("xoxb-...") with the chat:write scope, and
+	// Channel the channel ID or name to post to. With a bot token, the
+	// first message about a command is updated as it progresses and later
+	// messages reply in its thread.
+	Token   string
+	Channel string
+	// BaseURL is the Web API base URL, DefaultSlackAPIURL unless testing
+	// against a fake.
+	BaseURL string
 }
 
-// NewSlackNotifier creates a new instance of SlackNotifier.
+// NewSlackNotifier creates a new instance of SlackNotifier that posts to an
+// incoming webhook.
 func NewSlackNotifier(webhookURL string) *SlackNotifier {
 	return &SlackNotifier{WebhookURL: webhookURL}
 }
 
-// slackPayload is the JSON structure for a Slack message.
+// NewSlackBotNotifier creates a new instance of SlackNotifier that posts to
+// channel with a bot token.
+func NewSlackBotNotifier(token, channel string) *SlackNotifier {
+	return &SlackNotifier{Token: token, Channel: channel, BaseURL: DefaultSlackAPIURL}
+}
+
+// slackPayload is the JSON structure for a Slack message. Channel, TS,
+// ThreadTS and ReplyBroadcast are only used with the Web API.
 type slackPayload struct {
-	Text string `json:"text"`
+	Channel        string       `json:"channel,omitempty"`
+	TS             string       `json:"ts,omitempty"`
+	ThreadTS       string       `json:"thread_ts,omitempty"`
+	ReplyBroadcast bool         `json:"reply_broadcast,omitempty"`
+	Text           string       `json:"text"`
+	Blocks         []slackBlock `json:"blocks,omitempty"`
+}
+
+// slackBlock is a Block Kit layout block. Only the fields that apply to its
+// Type are set.
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Fields   []slackText `json:"fields,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+// slackText is a Block Kit text object.
+type slackText struct {
+	Type  string `json:"type"`
+	Text  string `json:"text"`
+	Emoji bool   `json:"emoji,omitempty"`
+}
+
+// slackAttachment is a secondary message attachment, used to render the
+// event metadata as fields next to a colored bar. Slack has replaced
+// attachments with blocks, but Mattermost and Rocket.Chat still use them.
+type slackAttachment struct {
+	Fallback string       `json:"fallback,omitempty"`
+	Color    string       `json:"color,omitempty"`
+	Fields   []slackField `json:"fields"`
+}
+
+// slackField is a single title/value pair within an attachment.
+type slackField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
 }
 
-// Notify sends a message to the configured Slack webhook.
+// Notify sends a message to the configured Slack webhook or channel.
 func (s *SlackNotifier) Notify(title, message string) error {
 	// Format the message for Slack.
 	fullMessage := fmt.Sprintf("*%s*\n%s", title, message)
-	payload := slackPayload{Text: fullMessage}
+	if s.Token != "" {
+		_, err := s.call(context.Background(), "chat.postMessage", slackPayload{Channel: s.Channel, Text: fullMessage})
+		return err
+	}
+	return s.post(context.Background(), slackPayload{Text: fullMessage})
+}
 
+// slackOutputBytes limits the captured output included in a message.
+const slackOutputBytes = 3000
+
+// Limits Slack imposes on blocks.
+const (
+	slackSectionChars = 3000
+	slackMaxFields    = 10
+)
+
+// Send sends the event to Slack as a Block Kit message, with a header, the
+// event metadata as section fields, the host and directory as context, and
+// any captured output as a code block.
+func (s *SlackNotifier) Send(ctx context.Context, event Event) error {
+	payload := newSlackPayload(event)
+	if s.Token != "" {
+		return s.sendWebAPI(ctx, event, payload)
+	}
+	return s.post(ctx, payload)
+}
+
+// newSlackPayload renders the event as a Block Kit message.
+func newSlackPayload(event Event) slackPayload {
+	return slackPayload{
+		// The text is shown in notifications and by clients that cannot
+		// render blocks.
+		Text:   fmt.Sprintf("*%s*\n%s", event.Title(), event.Message()),
+		Blocks: newSlackBlocks(event),
+	}
+}
+
+// newSlackBlocks renders the event as Block Kit blocks.
+func newSlackBlocks(event Event) []slackBlock {
+	blocks := []slackBlock{
+		{Type: "header", Text: &slackText{Type: "plain_text", Text: statusEmoji(event) + " " + event.Title(), Emoji: true}},
+	}
+
+	section := slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: event.Message()}}
+	contextBlock := slackBlock{Type: "context"}
+	for _, f := range event.Fields() {
+		switch f.Name {
+		case "Command", "Started", "Finished":
+			// The command is in the message, and the start and end times
+			// add little to the duration.
+		case "Host":
+			contextBlock.Elements = append(contextBlock.Elements, slackText{Type: "mrkdwn", Text: ":computer: " + f.Value})
+		case "Directory":
+			contextBlock.Elements = append(contextBlock.Elements, slackText{Type: "mrkdwn", Text: ":file_folder: `" + f.Value + "`"})
+		default:
+			if len(section.Fields) < slackMaxFields {
+				section.Fields = append(section.Fields, slackText{Type: "mrkdwn", Text: fmt.Sprintf("*%s*\n%s", f.Name, f.Value)})
+			}
+		}
+	}
+	blocks = append(blocks, section)
+	if len(contextBlock.Elements) > 0 {
+		blocks = append(blocks, contextBlock)
+	}
+
+	// The code block fences count towards the section's limit.
+	if tail := event.OutputTail(0, slackSectionChars-16); tail != "" {
+		blocks = append(blocks, slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: slackCodeBlock(tail)}})
+	}
+	return blocks
+}
+
+// slackCodeBlock wraps text in a Slack code block. Backtick fences inside
+// the text would end the block early, so they are broken up.
+func slackCodeBlock(text string) string {
+	return "```\n" + strings.ReplaceAll(text, "```", "`\u200b``") + "\n```"
+}
+
+// newSlackAttachment renders the event metadata as a Slack attachment with
+// the given color.
+func newSlackAttachment(event Event, color string) slackAttachment {
+	attachment := slackAttachment{Fallback: event.Title(), Color: color}
+	for _, f := range event.Fields() {
+		attachment.Fields = append(attachment.Fields, slackField{
+			Title: f.Name,
+			Value: f.Value,
+			// The command line is usually too long to share a row.
+			Short: f.Name != "Command",
+		})
+	}
+	return attachment
+}
+
+// slackHexColor returns the attachment color for the event's outcome as a
+// hex code, which every Slack-compatible service understands.
+func slackHexColor(event Event) string {
+	switch event.Outcome {
+	case OutcomeSucceeded:
+		return "#2EB67D"
+	case OutcomeFailed, OutcomeKilled, OutcomeTimedOut:
+		return "#E01E5A"
+	case OutcomeInterrupted:
+		return "#ECB22E"
+	default:
+		return ""
+	}
+}
+
+// slackMessageRef identifies a message posted through the Web API.
+type slackMessageRef struct {
+	Channel string
+	TS      string
+}
+
+// slackRuns remembers the first message posted about each command, keyed by
+// channel and command line. An nf process only runs one command, so this
+// ties heartbeats, matches and the final notification of a run together.
+var slackRuns = struct {
+	sync.Mutex
+	messages map[string]slackMessageRef
+}{messages: map[string]slackMessageRef{}}
+
+// runKey identifies the run the event belongs to in slackRuns.
+func (s *SlackNotifier) runKey(event Event) string {
+	return s.BaseURL + "\x00" + s.Channel + "\x00" + event.CommandLine()
+}
+
+// sendWebAPI posts the first message about a run and then keeps it current:
+// heartbeats update it in place, matches reply in its thread, and the final
+// notification updates it and is also posted to the thread and the channel,
+// since edits do not notify anyone.
+func (s *SlackNotifier) sendWebAPI(ctx context.Context, event Event, payload slackPayload) error {
+	// Holding the lock while posting keeps concurrent heartbeats and
+	// matches from each starting a message of their own.
+	slackRuns.Lock()
+	defer slackRuns.Unlock()
+
+	key := s.runKey(event)
+	parent, ok := slackRuns.messages[key]
+	if !ok {
+		payload.Channel = s.Channel
+		ref, err := s.call(ctx, "chat.postMessage", payload)
+		if err != nil {
+			return err
+		}
+		slackRuns.messages[key] = ref
+		return nil
+	}
+
+	switch event.Outcome {
+	case OutcomeRunning:
+		return s.update(ctx, parent, payload)
+	case OutcomeMatched:
+		payload.Channel, payload.ThreadTS = parent.Channel, parent.TS
+		_, err := s.call(ctx, "chat.postMessage", payload)
+		return err
+	default:
+		if err := s.update(ctx, parent, payload); err != nil {
+			return err
+		}
+		delete(slackRuns.messages, key)
+		payload.Channel, payload.ThreadTS, payload.ReplyBroadcast = parent.Channel, parent.TS, true
+		_, err := s.call(ctx, "chat.postMessage", payload)
+		return err
+	}
+}
+
+// FinishRun updates the message posted about the event's command, if its
+// result has not been sent to Slack yet, without posting anything new. It
+// is used when routing sends the result elsewhere, so that the message
+// does not keep saying that the command is running.
+func (s *SlackNotifier) FinishRun(ctx context.Context, event Event) error {
+	slackRuns.Lock()
+	defer slackRuns.Unlock()
+
+	key := s.runKey(event)
+	parent, ok := slackRuns.messages[key]
+	if !ok {
+		return nil
+	}
+	delete(slackRuns.messages, key)
+	return s.update(ctx, parent, newSlackPayload(event))
+}
+
+// update replaces the content of a message posted earlier.
+func (s *SlackNotifier) update(ctx context.Context, ref slackMessageRef, payload slackPayload) error {
+	payload.Channel, payload.TS = ref.Channel, ref.TS
+	_, err := s.call(ctx, "chat.update", payload)
+	return err
+}
+
+// call calls a Web API method and returns the message it posted or updated.
+func (s *SlackNotifier) call(ctx context.Context, method string, payload slackPayload) (slackMessageRef, error) {
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal slack payload: %w", err)
+		return slackMessageRef{}, fmt.Errorf("failed to marshal slack payload: %w", err)
 	}
 
-	resp, err := http.Post(s.WebhookURL, "application/json", bytes.NewBuffer(payloadBytes))
+	baseURL := s.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultSlackAPIURL
+	}
+	body, err := postJSON(ctx, strings.TrimRight(baseURL, "/")+"/"+method, map[string]string{
+		"Content-Type":  "application/json; charset=utf-8",
+		"Authorization": "Bearer " + s.Token,
+	}, payloadBytes)
 	if err != nil {
-		return fmt.Errorf("failed to send slack notification: %w", err)
+		return slackMessageRef{}, fmt.Errorf("failed to send slack notification: %s: %w", method, err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 400 {
-		return fmt.Errorf("failed to send slack notification: received status code %d", resp.StatusCode)
+	// The Web API reports errors such as channel_not_found with a 200
+	// status and "ok": false.
+	var result struct {
+		OK      bool   `json:"ok"`
+		Error   string `json:"error"`
+		Channel string `json:"channel"`
+		TS      string `json:"ts"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return slackMessageRef{}, fmt.Errorf("failed to decode slack %s response: %w", method, err)
+	}
+	if !result.OK {
+		return slackMessageRef{}, fmt.Errorf("failed to send slack notification: %s: %s", method, result.Error)
+	}
+	return slackMessageRef{Channel: result.Channel, TS: result.TS}, nil
+}
+
+func (s *SlackNotifier) post(ctx context.Context, payload slackPayload) error {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal slack payload: %w", err)
 	}
 
+	if _, err := postJSON(ctx, s.WebhookURL, nil, payloadBytes); err != nil {
+		return fmt.Errorf("failed to send slack notification: %w", err)
+	}
 	return nil
 }
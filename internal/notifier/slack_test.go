@@ -0,0 +1,193 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackNotifier_SendWebhook(t *testing.T) {
+	event := Event{
+		Command:  "make",
+		Args:     []string{"test"},
+		Outcome:  OutcomeFailed,
+		ExitCode: 2,
+		Duration: 90 * time.Second,
+		Host:     "labbox",
+		Dir:      "/src/nf",
+		Output:   "FAIL: TestParse",
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method, "Expected POST request")
+
+		bodyBytes, err := io.ReadAll(r.Body)
+		require.NoError(t, err, "Failed to read request body")
+
+		var payload slackPayload
+		require.NoError(t, json.Unmarshal(bodyBytes, &payload), "Failed to unmarshal request body")
+		assert.Equal(t, "*Command Failed: make*\nCommand `make test` failed with exit code 2 after 90.00 seconds.", payload.Text)
+		assert.Empty(t, payload.Channel)
+
+		assert.Equal(t, []slackBlock{
+			{Type: "header", Text: &slackText{Type: "plain_text", Text: "❌ Command Failed: make", Emoji: true}},
+			{Type: "section", Text: &slackText{Type: "mrkdwn", Text: "Command `make test` failed with exit code 2 after 90.00 seconds."}, Fields: []slackText{
+				{Type: "mrkdwn", Text: "*Status*\nfailed with exit code 2"},
+				{Type: "mrkdwn", Text: "*Exit Code*\n2"},
+				{Type: "mrkdwn", Text: "*Duration*\n1m30s"},
+			}},
+			{Type: "context", Elements: []slackText{
+				{Type: "mrkdwn", Text: ":computer: labbox"},
+				{Type: "mrkdwn", Text: ":file_folder: `/src/nf`"},
+			}},
+			{Type: "section", Text: &slackText{Type: "mrkdwn", Text: "```\nFAIL: TestParse\n```"}},
+		}, payload.Blocks)
+
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	n := NewSlackNotifier(server.URL)
+	assert.NoError(t, n.Send(context.Background(), event), "Send returned an unexpected error")
+}
+
+// slackRequest is a Web API call received by fakeSlackAPI.
+type slackRequest struct {
+	Method  string
+	Payload slackPayload
+}
+
+// fakeSlackAPI answers chat.postMessage and chat.update calls like Slack
+// and records them.
+func fakeSlackAPI(t *testing.T) (*httptest.Server, func() []slackRequest) {
+	var mu sync.Mutex
+	var requests []slackRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer xoxb-token", r.Header.Get("Authorization"))
+
+		var payload slackPayload
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload), "Failed to decode request body")
+
+		mu.Lock()
+		requests = append(requests, slackRequest{Method: strings.TrimPrefix(r.URL.Path, "/api/"), Payload: payload})
+		ts := "1700000000.00000" + string(rune('0'+len(requests)))
+		mu.Unlock()
+
+		if payload.Channel == "#missing" {
+			json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": "channel_not_found"})
+			return
+		}
+		if payload.TS != "" {
+			ts = payload.TS
+		}
+		json.NewEncoder(w).Encode(map[string]any{"ok": true, "channel": "C123", "ts": ts})
+	}))
+	return server, func() []slackRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]slackRequest(nil), requests...)
+	}
+}
+
+func TestSlackNotifier_SendWebAPI(t *testing.T) {
+	slackRuns.messages = map[string]slackMessageRef{}
+	server, requests := fakeSlackAPI(t)
+	defer server.Close()
+
+	n := NewSlackBotNotifier("xoxb-token", "#builds")
+	n.BaseURL = server.URL + "/api/"
+	event := Event{Command: "make", Args: []string{"release"}, Duration: time.Minute}
+
+	for _, outcome := range []Outcome{OutcomeRunning, OutcomeRunning, OutcomeMatched, OutcomeSucceeded} {
+		event.Outcome = outcome
+		require.NoError(t, n.Send(context.Background(), event), "Send returned an unexpected error")
+	}
+
+	// A different command gets a message of its own.
+	event.Args = []string{"clean"}
+	require.NoError(t, n.Send(context.Background(), event), "Send returned an unexpected error")
+
+	got := requests()
+	require.Len(t, got, 6)
+
+	// The first heartbeat starts the message in the configured channel...
+	assert.Equal(t, "chat.postMessage", got[0].Method)
+	assert.Equal(t, "#builds", got[0].Payload.Channel)
+	assert.Empty(t, got[0].Payload.ThreadTS)
+	assert.Equal(t, "⏳ Command Still Running: make", got[0].Payload.Blocks[0].Text.Text)
+	parent := "1700000000.000001"
+
+	// ...later heartbeats update it...
+	assert.Equal(t, "chat.update", got[1].Method)
+	assert.Equal(t, slackPayload{Channel: "C123", TS: parent}, slackPayload{Channel: got[1].Payload.Channel, TS: got[1].Payload.TS})
+
+	// ...matches reply in its thread...
+	assert.Equal(t, "chat.postMessage", got[2].Method)
+	assert.Equal(t, parent, got[2].Payload.ThreadTS)
+	assert.False(t, got[2].Payload.ReplyBroadcast)
+
+	// ...and the result updates it and is broadcast from the thread.
+	assert.Equal(t, "chat.update", got[3].Method)
+	assert.Equal(t, parent, got[3].Payload.TS)
+	assert.Equal(t, "✅ Command Succeeded: make", got[3].Payload.Blocks[0].Text.Text)
+	assert.Equal(t, "chat.postMessage", got[4].Method)
+	assert.Equal(t, parent, got[4].Payload.ThreadTS)
+	assert.True(t, got[4].Payload.ReplyBroadcast)
+
+	assert.Equal(t, "chat.postMessage", got[5].Method)
+	assert.Empty(t, got[5].Payload.ThreadTS)
+	assert.Contains(t, got[5].Payload.Text, "`make clean`")
+}
+
+func TestSlackNotifier_FinishRun(t *testing.T) {
+	slackRuns.messages = map[string]slackMessageRef{}
+	server, requests := fakeSlackAPI(t)
+	defer server.Close()
+
+	n := NewSlackBotNotifier("xoxb-token", "#builds")
+	n.BaseURL = server.URL + "/api/"
+	event := Event{Command: "make", Outcome: OutcomeSucceeded}
+
+	// Without a running message there is nothing to update.
+	require.NoError(t, n.FinishRun(context.Background(), event))
+	assert.Empty(t, requests())
+
+	event.Outcome = OutcomeRunning
+	require.NoError(t, n.Send(context.Background(), event))
+	event.Outcome = OutcomeSucceeded
+	require.NoError(t, n.FinishRun(context.Background(), event))
+	require.NoError(t, n.FinishRun(context.Background(), event))
+
+	got := requests()
+	require.Len(t, got, 2, "Expected the running message to be updated once")
+	assert.Equal(t, "chat.update", got[1].Method)
+	assert.Equal(t, "✅ Command Succeeded: make", got[1].Payload.Blocks[0].Text.Text)
+
+	// Once the result has been sent, FinishRun has nothing left to do.
+	event.Outcome = OutcomeRunning
+	require.NoError(t, n.Send(context.Background(), event))
+	event.Outcome = OutcomeFailed
+	require.NoError(t, n.Send(context.Background(), event))
+	require.NoError(t, n.FinishRun(context.Background(), event))
+	assert.Len(t, requests(), 5)
+}
+
+func TestSlackNotifier_WebAPIError(t *testing.T) {
+	slackRuns.messages = map[string]slackMessageRef{}
+	server, _ := fakeSlackAPI(t)
+	defer server.Close()
+
+	n := NewSlackBotNotifier("xoxb-token", "#missing")
+	n.BaseURL = server.URL + "/api"
+	err := n.Send(context.Background(), Event{Command: "make", Outcome: OutcomeFailed, ExitCode: 1})
+	assert.EqualError(t, err, "failed to send slack notification: chat.postMessage: channel_not_found")
+}
@@ -1,49 +1,254 @@
 package notifier
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
+	"html"
+	"strings"
+)
+
+// Payload formats for TeamsNotifier.
+const (
+	// TeamsAdaptiveCard sends an Adaptive Card in a message envelope, as
+	// accepted by Power Automate Workflows webhooks.
+	TeamsAdaptiveCard = "adaptive"
+	// TeamsMessageCard sends a legacy MessageCard, for Office 365 connector
+	// webhooks.
+	TeamsMessageCard = "messagecard"
 )
 
 // TeamsNotifier sends notifications to a Microsoft Teams webhook.
 type TeamsNotifier struct {
 	WebhookURL string
+	// Format is TeamsAdaptiveCard or TeamsMessageCard.
+	Format string
 }
 
-// NewTeamsNotifier creates a new instance of TeamsNotifier.
+// NewTeamsNotifier creates a new instance of TeamsNotifier that sends
+// Adaptive Cards.
 func NewTeamsNotifier(webhookURL string) *TeamsNotifier {
-	return &TeamsNotifier{WebhookURL: webhookURL}
+	return &TeamsNotifier{WebhookURL: webhookURL, Format: TeamsAdaptiveCard}
 }
 
-// teamsPayload is the JSON structure for a simple Teams message card.
+// teamsPayload is the JSON structure for a legacy Teams message card.
 type teamsPayload struct {
+	Type       string         `json:"@type,omitempty"`
+	Context    string         `json:"@context,omitempty"`
+	ThemeColor string         `json:"themeColor,omitempty"`
+	Title      string         `json:"title"`
+	Text       string         `json:"text"`
+	Sections   []teamsSection `json:"sections,omitempty"`
+}
+
+// teamsSection is a message card section holding the event facts or the
+// captured output.
+type teamsSection struct {
+	Text  string      `json:"text,omitempty"`
+	Facts []teamsFact `json:"facts,omitempty"`
+}
+
+// teamsFact is a single name/value pair in a message card section.
+type teamsFact struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// teamsMessage is the message envelope carrying an Adaptive Card.
+type teamsMessage struct {
+	Type        string            `json:"type"`
+	Attachments []teamsAttachment `json:"attachments"`
+}
+
+type teamsAttachment struct {
+	ContentType string            `json:"contentType"`
+	Content     teamsAdaptiveCard `json:"content"`
+}
+
+type teamsAdaptiveCard struct {
+	Schema  string         `json:"$schema"`
+	Type    string         `json:"type"`
+	Version string         `json:"version"`
+	Body    []teamsElement `json:"body"`
+	MSTeams struct {
+		Width string `json:"width"`
+	} `json:"msteams"`
+}
+
+// teamsElement is an Adaptive Card element. Only the fields that apply to
+// its Type are set.
+type teamsElement struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	Wrap     bool            `json:"wrap,omitempty"`
+	Weight   string          `json:"weight,omitempty"`
+	Size     string          `json:"size,omitempty"`
+	Color    string          `json:"color,omitempty"`
+	FontType string          `json:"fontType,omitempty"`
+	Style    string          `json:"style,omitempty"`
+	Bleed    bool            `json:"bleed,omitempty"`
+	Items    []teamsElement  `json:"items,omitempty"`
+	Facts    []teamsCardFact `json:"facts,omitempty"`
+}
+
+// teamsCardFact is a single title/value pair in an Adaptive Card FactSet.
+type teamsCardFact struct {
 	Title string `json:"title"`
-	Text  string `json:"text"`
+	Value string `json:"value"`
 }
 
 // Notify sends a message to the configured Teams webhook.
 func (t *TeamsNotifier) Notify(title, message string) error {
+	if t.Format == TeamsMessageCard {
+		payload := teamsPayload{
+			Title: title,
+			Text:  message,
+		}
+		return t.post(context.Background(), payload)
+	}
+	return t.post(context.Background(), newTeamsMessage(
+		teamsHeading(title, "default", "Default"),
+		teamsElement{Type: "TextBlock", Text: message, Wrap: true},
+	))
+}
+
+// teamsOutputBytes limits the captured output included in a card.
+const teamsOutputBytes = 3000
+
+// Send sends the event to the configured Teams webhook, with the event
+// metadata rendered as facts and any captured output as a preformatted
+// block.
+func (t *TeamsNotifier) Send(ctx context.Context, event Event) error {
+	if t.Format == TeamsMessageCard {
+		return t.post(ctx, newTeamsMessageCard(event))
+	}
+
+	style, color := teamsStyle(event)
+	facts := teamsElement{Type: "FactSet"}
+	for _, f := range event.Fields() {
+		facts.Facts = append(facts.Facts, teamsCardFact{Title: f.Name, Value: f.Value})
+	}
+	elements := []teamsElement{
+		teamsHeading(event.Title(), style, color),
+		{Type: "TextBlock", Text: event.Message(), Wrap: true},
+		facts,
+	}
+	if tail := event.OutputTail(0, teamsOutputBytes); tail != "" {
+		elements = append(elements, teamsElement{
+			Type:     "TextBlock",
+			Text:     tail,
+			Wrap:     true,
+			FontType: "Monospace",
+			Size:     "Small",
+		})
+	}
+	return t.post(ctx, newTeamsMessage(elements...))
+}
+
+// newTeamsMessage wraps the elements in an Adaptive Card message.
+func newTeamsMessage(elements ...teamsElement) teamsMessage {
+	card := teamsAdaptiveCard{
+		Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+		Type:    "AdaptiveCard",
+		Version: "1.4",
+		Body:    elements,
+	}
+	card.MSTeams.Width = "Full"
+	return teamsMessage{
+		Type: "message",
+		Attachments: []teamsAttachment{{
+			ContentType: "application/vnd.microsoft.card.adaptive",
+			Content:     card,
+		}},
+	}
+}
+
+// teamsHeading renders the title as a bold heading in a container with
+// the given style, so that the outcome stands out at a glance.
+func teamsHeading(title, style, color string) teamsElement {
+	return teamsElement{
+		Type:  "Container",
+		Style: style,
+		Bleed: true,
+		Items: []teamsElement{{
+			Type:   "TextBlock",
+			Text:   title,
+			Wrap:   true,
+			Weight: "Bolder",
+			Size:   "Medium",
+			Color:  color,
+		}},
+	}
+}
+
+// teamsStyle returns the container style and text color for the event's
+// outcome.
+func teamsStyle(event Event) (string, string) {
+	switch event.Outcome {
+	case OutcomeSucceeded:
+		return "good", "Good"
+	case OutcomeFailed, OutcomeKilled, OutcomeTimedOut:
+		return "attention", "Attention"
+	case OutcomeInterrupted:
+		return "warning", "Warning"
+	default:
+		return "emphasis", "Default"
+	}
+}
+
+// newTeamsMessageCard renders the event as a legacy message card.
+func newTeamsMessageCard(event Event) teamsPayload {
+	section := teamsSection{}
+	for _, f := range event.Fields() {
+		section.Facts = append(section.Facts, teamsFact{Name: f.Name, Value: f.Value})
+	}
+
 	payload := teamsPayload{
-		Title: title,
-		Text:  message,
+		Type:       "MessageCard",
+		Context:    "http://schema.org/extensions",
+		ThemeColor: teamsThemeColor(event),
+		Title:      event.Title(),
+		Text:       event.Message(),
+		Sections:   []teamsSection{section},
 	}
+	if tail := event.OutputTail(0, teamsOutputBytes); tail != "" {
+		payload.Sections = append(payload.Sections, teamsSection{
+			Text: "<pre>" + html.EscapeString(tail) + "</pre>",
+		})
+	}
+	return payload
+}
+
+// teamsThemeColor returns the card accent color for the event's outcome.
+func teamsThemeColor(event Event) string {
+	switch event.Outcome {
+	case OutcomeSucceeded:
+		return "2EB67D"
+	case OutcomeFailed, OutcomeKilled, OutcomeTimedOut:
+		return "E01E5A"
+	case OutcomeInterrupted:
+		return "ECB22E"
+	default:
+		return ""
+	}
+}
 
+func (t *TeamsNotifier) post(ctx context.Context, payload any) error {
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("failed to marshal teams payload: %w", err)
 	}
 
-	resp, err := http.Post(t.WebhookURL, "application/json", bytes.NewBuffer(payloadBytes))
+	body, err := postJSON(ctx, t.WebhookURL, nil, payloadBytes)
 	if err != nil {
 		return fmt.Errorf("failed to send teams notification: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 400 {
-		// Teams returns 200 on success, but the body contains "1"
-		return fmt.Errorf("failed to send teams notification: received status code %d", resp.StatusCode)
+	// Workflows webhooks accept a message with an empty body, connector
+	// webhooks with "1". Connectors report some errors, such as a payload
+	// that is too large, with a 200 status and the error as the body.
+	if text := strings.TrimSpace(string(body)); text != "" && text != "1" {
+		return fmt.Errorf("failed to send teams notification: %s", text)
 	}
 
 	return nil
@@ -0,0 +1,124 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeamsNotifier_SendAdaptiveCard(t *testing.T) {
+	event := Event{
+		Command:  "make",
+		Args:     []string{"test"},
+		Outcome:  OutcomeFailed,
+		ExitCode: 2,
+		Duration: 90 * time.Second,
+		Host:     "labbox",
+		Output:   "FAIL: TestParse",
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method, "Expected POST request")
+
+		bodyBytes, err := io.ReadAll(r.Body)
+		require.NoError(t, err, "Failed to read request body")
+
+		var payload teamsMessage
+		require.NoError(t, json.Unmarshal(bodyBytes, &payload), "Failed to unmarshal request body")
+		assert.Equal(t, "message", payload.Type)
+		require.Len(t, payload.Attachments, 1)
+		assert.Equal(t, "application/vnd.microsoft.card.adaptive", payload.Attachments[0].ContentType)
+
+		card := payload.Attachments[0].Content
+		assert.Equal(t, "AdaptiveCard", card.Type)
+		require.Len(t, card.Body, 4)
+
+		heading := card.Body[0]
+		assert.Equal(t, "Container", heading.Type)
+		assert.Equal(t, "attention", heading.Style)
+		require.Len(t, heading.Items, 1)
+		assert.Equal(t, "Command Failed: make", heading.Items[0].Text)
+		assert.Equal(t, "Attention", heading.Items[0].Color)
+
+		assert.Equal(t, "Command `make test` failed with exit code 2 after 90.00 seconds.", card.Body[1].Text)
+		assert.Equal(t, "FactSet", card.Body[2].Type)
+		assert.Equal(t, []teamsCardFact{
+			{Title: "Command", Value: "make test"},
+			{Title: "Status", Value: "failed with exit code 2"},
+			{Title: "Exit Code", Value: "2"},
+			{Title: "Duration", Value: "1m30s"},
+			{Title: "Host", Value: "labbox"},
+		}, card.Body[2].Facts)
+		assert.Equal(t, "Monospace", card.Body[3].FontType)
+		assert.Equal(t, "FAIL: TestParse", card.Body[3].Text)
+
+		// Workflows webhooks answer with 202 and no body.
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	n := NewTeamsNotifier(server.URL)
+	assert.NoError(t, n.Send(context.Background(), event), "Send returned an unexpected error")
+}
+
+func TestTeamsNotifier_SendMessageCard(t *testing.T) {
+	event := Event{Command: "make", Outcome: OutcomeSucceeded, Duration: time.Second, Output: "<ok>"}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bodyBytes, err := io.ReadAll(r.Body)
+		require.NoError(t, err, "Failed to read request body")
+
+		var payload teamsPayload
+		require.NoError(t, json.Unmarshal(bodyBytes, &payload), "Failed to unmarshal request body")
+		assert.Equal(t, "MessageCard", payload.Type)
+		assert.Equal(t, "2EB67D", payload.ThemeColor)
+		assert.Equal(t, "Command Succeeded: make", payload.Title)
+		require.Len(t, payload.Sections, 2)
+		assert.Equal(t, "<pre>&lt;ok&gt;</pre>", payload.Sections[1].Text)
+
+		w.Write([]byte("1"))
+	}))
+	defer server.Close()
+
+	n := NewTeamsNotifier(server.URL)
+	n.Format = TeamsMessageCard
+	assert.NoError(t, n.Send(context.Background(), event), "Send returned an unexpected error")
+}
+
+func TestTeamsNotifier_Response(t *testing.T) {
+	testCases := []struct {
+		name        string
+		status      int
+		body        string
+		expectedErr string
+	}{
+		{name: "connector success", status: http.StatusOK, body: "1"},
+		{name: "workflow success", status: http.StatusAccepted},
+		{name: "error in body", status: http.StatusOK, body: "Microsoft Teams endpoint returned HTTP error 413 with ContextId MS-CV=abc.", expectedErr: "failed to send teams notification: Microsoft Teams endpoint returned HTTP error 413 with ContextId MS-CV=abc."},
+		{name: "error status", status: http.StatusBadRequest, body: "Bad payload", expectedErr: "failed to send teams notification: received status code 400"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+
+			err := NewTeamsNotifier(server.URL).Notify("Test Title", "Test Message")
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DefaultTelegramAPIURL is the Telegram Bot API server used unless
+// TelegramNotifier.BaseURL is changed.
+const DefaultTelegramAPIURL = "https://api.telegram.org"
+
+// Parse modes supported by TelegramNotifier.
+const (
+	TelegramHTML       = "HTML"
+	TelegramMarkdownV2 = "MarkdownV2"
+)
+
+// TelegramNotifier sends notifications through a Telegram bot.
+type TelegramNotifier struct {
+	BaseURL string
+	Token   string
+	ChatID  string
+
+	// ParseMode is TelegramHTML or TelegramMarkdownV2.
+	ParseMode string
+	// SilentSuccess delivers notifications about successful commands
+	// without a sound.
+	SilentSuccess bool
+	// AttachOutput uploads the captured output as a log file replying to
+	// the message, instead of including its tail in the message.
+	AttachOutput bool
+}
+
+// NewTelegramNotifier creates a new instance of TelegramNotifier that
+// sends HTML-formatted messages through the public Bot API server.
+func NewTelegramNotifier(token, chatID string) *TelegramNotifier {
+	return &TelegramNotifier{
+		BaseURL:   DefaultTelegramAPIURL,
+		Token:     token,
+		ChatID:    chatID,
+		ParseMode: TelegramHTML,
+	}
+}
+
+// telegramMessage is the JSON structure for the sendMessage method.
+type telegramMessage struct {
+	ChatID              string `json:"chat_id"`
+	Text                string `json:"text"`
+	ParseMode           string `json:"parse_mode,omitempty"`
+	DisableNotification bool   `json:"disable_notification,omitempty"`
+}
+
+// telegramResponse is the envelope of every Bot API response.
+type telegramResponse struct {
+	OK          bool   `json:"ok"`
+	Description string `json:"description"`
+	Result      struct {
+		MessageID int `json:"message_id"`
+	} `json:"result"`
+}
+
+// telegramOutputBytes limits the captured output included in a message,
+// which Telegram limits to 4096 characters in total.
+const telegramOutputBytes = 3000
+
+// Notify sends a message through the configured Telegram bot.
+func (t *TelegramNotifier) Notify(title, message string) error {
+	_, err := t.sendMessage(context.Background(), telegramMessage{
+		ChatID:    t.ChatID,
+		Text:      t.bold(title) + "\n" + t.format(message),
+		ParseMode: t.ParseMode,
+	})
+	return err
+}
+
+// Send sends the event through the configured Telegram bot. The command
+// line is shown as code, and the captured output is either appended as a
+// preformatted block or, with AttachOutput, uploaded as a file.
+func (t *TelegramNotifier) Send(ctx context.Context, event Event) error {
+	text := t.bold(event.Title()) + "\n" + t.format(event.Message())
+	var lines []string
+	for _, f := range event.Fields() {
+		switch f.Name {
+		case "Command", "Status", "Duration", "Started", "Finished":
+			// Already part of the message, or implied by when it arrives.
+		case "Exit Code":
+			// Only worth repeating if the message names a signal instead.
+			if event.Outcome != OutcomeSucceeded && event.Outcome != OutcomeFailed {
+				lines = append(lines, t.format(f.Name+": "+f.Value))
+			}
+		default:
+			lines = append(lines, t.format(f.Name+": "+f.Value))
+		}
+	}
+	if len(lines) > 0 {
+		text += "\n\n" + strings.Join(lines, "\n")
+	}
+	attach := t.AttachOutput && event.Output != ""
+	if tail := event.OutputTail(0, telegramOutputBytes); tail != "" && !attach {
+		text += "\n" + t.pre(tail)
+	}
+
+	messageID, err := t.sendMessage(ctx, telegramMessage{
+		ChatID:              t.ChatID,
+		Text:                text,
+		ParseMode:           t.ParseMode,
+		DisableNotification: t.SilentSuccess && event.Success(),
+	})
+	if err != nil {
+		return err
+	}
+	if attach {
+		return t.sendDocument(ctx, messageID, "output.log", event.Output)
+	}
+	return nil
+}
+
+// bold formats text in bold for the parse mode.
+func (t *TelegramNotifier) bold(text string) string {
+	if t.ParseMode == TelegramMarkdownV2 {
+		return "*" + escapeTelegramMarkdown(text) + "*"
+	}
+	return "<b>" + html.EscapeString(text) + "</b>"
+}
+
+// pre formats text as a preformatted block for the parse mode.
+func (t *TelegramNotifier) pre(text string) string {
+	if t.ParseMode == TelegramMarkdownV2 {
+		return "```\n" + escapeTelegramCode(text) + "\n```"
+	}
+	return "<pre>" + html.EscapeString(text) + "</pre>"
+}
+
+// format escapes text for the parse mode, rendering spans in backticks,
+// such as the command line in an event message, as inline code.
+func (t *TelegramNotifier) format(text string) string {
+	var b strings.Builder
+	for i, part := range strings.Split(text, "`") {
+		code := i%2 == 1
+		switch {
+		case t.ParseMode == TelegramMarkdownV2 && code:
+			b.WriteString("`" + escapeTelegramCode(part) + "`")
+		case t.ParseMode == TelegramMarkdownV2:
+			b.WriteString(escapeTelegramMarkdown(part))
+		case code:
+			b.WriteString("<code>" + html.EscapeString(part) + "</code>")
+		default:
+			b.WriteString(html.EscapeString(part))
+		}
+	}
+	return b.String()
+}
+
+// escapeTelegramMarkdown escapes the characters that have a meaning in
+// MarkdownV2 text.
+func escapeTelegramMarkdown(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		if strings.ContainsRune("_*[]()~`>#+-=|{}.!\\", r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// escapeTelegramCode escapes the characters that have a meaning inside
+// MarkdownV2 code spans and blocks.
+func escapeTelegramCode(text string) string {
+	return strings.NewReplacer("\\", "\\\\", "`", "\\`").Replace(text)
+}
+
+// sendMessage calls the sendMessage method and returns the ID of the sent
+// message.
+func (t *TelegramNotifier) sendMessage(ctx context.Context, message telegramMessage) (int, error) {
+	payloadBytes, err := json.Marshal(message)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal telegram payload: %w", err)
+	}
+
+	data, err := postJSON(ctx, t.methodURL("sendMessage"), nil, payloadBytes)
+	return t.result(data, err)
+}
+
+// sendDocument uploads content as a file replying to the message with the
+// given ID.
+func (t *TelegramNotifier) sendDocument(ctx context.Context, replyTo int, filename, content string) error {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("chat_id", t.ChatID)
+	form.WriteField("disable_notification", "true")
+	if replyTo != 0 {
+		form.WriteField("reply_to_message_id", strconv.Itoa(replyTo))
+	}
+	part, err := form.CreateFormFile("document", filename)
+	if err != nil {
+		return fmt.Errorf("failed to create telegram upload: %w", err)
+	}
+	io.WriteString(part, content)
+	if err := form.Close(); err != nil {
+		return fmt.Errorf("failed to create telegram upload: %w", err)
+	}
+
+	data, err := sendHTTP(ctx, http.MethodPost, t.methodURL("sendDocument"), map[string]string{"Content-Type": form.FormDataContentType()}, &body)
+	_, err = t.result(data, err)
+	return err
+}
+
+// methodURL returns the URL of a Bot API method.
+func (t *TelegramNotifier) methodURL(method string) string {
+	return fmt.Sprintf("%s/bot%s/%s", strings.TrimRight(t.BaseURL, "/"), t.Token, method)
+}
+
+// result decodes the response to a Bot API request and returns the ID of
+// the resulting message. The Bot API explains errors in the body.
+func (t *TelegramNotifier) result(data []byte, err error) (int, error) {
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		data = statusErr.Body
+	} else if err != nil {
+		return 0, fmt.Errorf("failed to send telegram notification: %w", err)
+	}
+
+	var result telegramResponse
+	decodeErr := json.Unmarshal(data, &result)
+	if statusErr != nil || !result.OK {
+		if result.Description != "" {
+			return 0, fmt.Errorf("failed to send telegram notification: %s", result.Description)
+		}
+		if statusErr != nil {
+			return 0, fmt.Errorf("failed to send telegram notification: %w", err)
+		}
+		if decodeErr != nil {
+			return 0, fmt.Errorf("failed to send telegram notification: invalid response: %w", decodeErr)
+		}
+		return 0, fmt.Errorf("failed to send telegram notification: invalid response")
+	}
+	return result.Result.MessageID, nil
+}
@@ -0,0 +1,137 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTelegramNotifier_Send(t *testing.T) {
+	event := Event{
+		Command:  "grep",
+		Args:     []string{"-r", "<TODO>", "."},
+		Outcome:  OutcomeFailed,
+		ExitCode: 1,
+		Duration: 12 * time.Second,
+		Host:     "buildbox",
+		Output:   "a < b && c",
+	}
+
+	testCases := []struct {
+		name         string
+		parseMode    string
+		event        Event
+		expectedText string
+		expectSilent bool
+	}{
+		{
+			name:         "html",
+			parseMode:    TelegramHTML,
+			event:        event,
+			expectedText: "<b>Command Failed: grep</b>\nCommand <code>grep -r &lt;TODO&gt; .</code> failed with exit code 1 after 12.00 seconds.\n\nHost: buildbox\n<pre>a &lt; b &amp;&amp; c</pre>",
+		},
+		{
+			name:         "markdownv2",
+			parseMode:    TelegramMarkdownV2,
+			event:        event,
+			expectedText: "*Command Failed: grep*\nCommand `grep -r <TODO> .` failed with exit code 1 after 12\\.00 seconds\\.\n\nHost: buildbox\n```\na < b && c\n```",
+		},
+		{
+			name:         "silent success",
+			parseMode:    TelegramHTML,
+			event:        Event{Command: "make", Outcome: OutcomeSucceeded, Duration: time.Second},
+			expectedText: "<b>Command Succeeded: make</b>\nCommand <code>make</code> succeeded in 1.00 seconds.",
+			expectSilent: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/botTOKEN/sendMessage", r.URL.Path)
+
+				bodyBytes, err := io.ReadAll(r.Body)
+				require.NoError(t, err, "Failed to read request body")
+				defer r.Body.Close()
+
+				var payload telegramMessage
+				require.NoError(t, json.Unmarshal(bodyBytes, &payload), "Failed to unmarshal request body")
+
+				assert.Equal(t, "-100123", payload.ChatID)
+				assert.Equal(t, tc.parseMode, payload.ParseMode)
+				assert.Equal(t, tc.expectedText, payload.Text)
+				assert.Equal(t, tc.expectSilent, payload.DisableNotification)
+
+				io.WriteString(w, `{"ok": true, "result": {"message_id": 7}}`)
+			}))
+			defer server.Close()
+
+			n := NewTelegramNotifier("TOKEN", "-100123")
+			n.BaseURL = server.URL
+			n.ParseMode = tc.parseMode
+			n.SilentSuccess = true
+
+			assert.NoError(t, n.Send(context.Background(), tc.event), "Send returned an unexpected error")
+		})
+	}
+}
+
+func TestTelegramNotifier_AttachOutput(t *testing.T) {
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.URL.Path)
+		switch r.URL.Path {
+		case "/botTOKEN/sendMessage":
+			bodyBytes, err := io.ReadAll(r.Body)
+			require.NoError(t, err, "Failed to read request body")
+			assert.NotContains(t, string(bodyBytes), "<pre>", "Expected the output to be uploaded instead")
+			io.WriteString(w, `{"ok": true, "result": {"message_id": 7}}`)
+		case "/botTOKEN/sendDocument":
+			require.NoError(t, r.ParseMultipartForm(1<<20))
+			assert.Equal(t, "-100123", r.FormValue("chat_id"))
+			assert.Equal(t, "7", r.FormValue("reply_to_message_id"))
+			file, header, err := r.FormFile("document")
+			require.NoError(t, err)
+			defer file.Close()
+			content, _ := io.ReadAll(file)
+			assert.Equal(t, "output.log", header.Filename)
+			assert.Equal(t, "line 1\nline 2\n", string(content))
+			io.WriteString(w, `{"ok": true, "result": {"message_id": 8}}`)
+		}
+	}))
+	defer server.Close()
+
+	n := NewTelegramNotifier("TOKEN", "-100123")
+	n.BaseURL = server.URL
+	n.AttachOutput = true
+
+	event := Event{Command: "make", Outcome: OutcomeFailed, ExitCode: 2, Output: "line 1\nline 2\n"}
+	assert.NoError(t, n.Send(context.Background(), event))
+	assert.Equal(t, []string{"/botTOKEN/sendMessage", "/botTOKEN/sendDocument"}, methods)
+}
+
+func TestTelegramNotifier_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, `{"ok": false, "error_code": 400, "description": "Bad Request: chat not found"}`)
+	}))
+	defer server.Close()
+
+	n := NewTelegramNotifier("SECRET", "1")
+	n.BaseURL = server.URL
+	err := n.Notify("Test Title", "Test Message")
+	assert.EqualError(t, err, "failed to send telegram notification: Bad Request: chat not found")
+
+	// Connection errors must not reveal the bot token in the URL.
+	server.Close()
+	err = n.Notify("Test Title", "Test Message")
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "SECRET")
+}
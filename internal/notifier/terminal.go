@@ -0,0 +1,158 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Escape sequences TerminalNotifier can send.
+const (
+	// TerminalOSC9 is understood by iTerm2, Windows Terminal, ConEmu and
+	// WezTerm.
+	TerminalOSC9 = "osc9"
+	// TerminalOSC777 is understood by urxvt, foot, WezTerm and VTE-based
+	// terminals such as GNOME Terminal.
+	TerminalOSC777 = "osc777"
+	// TerminalOSC99 is kitty's notification protocol.
+	TerminalOSC99 = "osc99"
+	// TerminalBell rings the bell, which most terminals can turn into a
+	// visual or desktop alert.
+	TerminalBell = "bel"
+)
+
+// terminalTextBytes limits the title and body, since some terminals and
+// screen's passthrough drop overly long sequences.
+const terminalTextBytes = 256
+
+// TerminalNotifier writes notifications as escape sequences to the
+// terminal, so that the terminal emulator shows them on the desktop it runs
+// on, even if nf itself runs on a remote machine over SSH.
+type TerminalNotifier struct {
+	// Protocols lists the escape sequences to send. If empty, they are
+	// detected from the environment.
+	Protocols []string
+	// TTY is the terminal to write to, the controlling terminal by default.
+	TTY string
+}
+
+// NewTerminalNotifier creates a new instance of TerminalNotifier that
+// detects the terminal's protocols and writes to the controlling terminal.
+func NewTerminalNotifier() *TerminalNotifier {
+	return &TerminalNotifier{}
+}
+
+// Notify writes a notification to the terminal.
+func (t *TerminalNotifier) Notify(title, message string) error {
+	protocols := t.Protocols
+	if len(protocols) == 0 {
+		protocols = detectTerminalProtocols(os.Getenv)
+	}
+	seq := terminalSequences(title, message, protocols, os.Getenv)
+
+	tty := t.TTY
+	if tty == "" {
+		tty = "/dev/tty"
+		if runtime.GOOS == "windows" {
+			tty = "CONOUT$"
+		}
+	}
+	f, err := os.OpenFile(tty, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open terminal: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(seq); err != nil {
+		return fmt.Errorf("failed to write terminal notification: %w", err)
+	}
+	return nil
+}
+
+// Send writes a notification with the event's title and message to the
+// terminal.
+func (t *TerminalNotifier) Send(ctx context.Context, event Event) error {
+	return t.Notify(event.Title(), event.Message())
+}
+
+// detectTerminalProtocols guesses which notification sequence the terminal
+// understands. Over SSH, only TERM and the LC_* variables usually reach nf,
+// so those are checked as well as the variables terminals set locally. If
+// the terminal is not recognized, only the bell is used.
+func detectTerminalProtocols(getenv func(string) string) []string {
+	term := getenv("TERM")
+	switch {
+	case getenv("KITTY_WINDOW_ID") != "" || term == "xterm-kitty":
+		return []string{TerminalOSC99}
+	case getenv("TERM_PROGRAM") == "iTerm.app" || getenv("LC_TERMINAL") == "iTerm2" || getenv("WT_SESSION") != "":
+		return []string{TerminalOSC9}
+	case getenv("TERM_PROGRAM") == "WezTerm" || term == "wezterm" ||
+		strings.HasPrefix(term, "foot") || strings.HasPrefix(term, "rxvt") || getenv("VTE_VERSION") != "":
+		return []string{TerminalOSC777}
+	default:
+		return []string{TerminalBell}
+	}
+}
+
+// terminalSequences renders the notification in each of the protocols. If
+// nf runs inside tmux or screen, the OSC sequences are wrapped so that the
+// multiplexer passes them on to the terminal instead of swallowing them.
+func terminalSequences(title, body string, protocols []string, getenv func(string) string) string {
+	title = sanitizeTerminalText(title)
+	body = sanitizeTerminalText(body)
+
+	wrap := func(seq string) string { return seq }
+	if getenv("TMUX") != "" {
+		// Requires "set -g allow-passthrough on" in tmux 3.3 and later.
+		wrap = func(seq string) string {
+			return "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+		}
+	} else if getenv("STY") != "" {
+		wrap = func(seq string) string { return "\x1bP" + seq + "\x1b\\" }
+	}
+
+	var b strings.Builder
+	for _, protocol := range protocols {
+		switch protocol {
+		case TerminalOSC9:
+			b.WriteString(wrap("\x1b]9;" + title + ": " + body + "\a"))
+		case TerminalOSC777:
+			// Fields are separated by semicolons, so the title cannot
+			// contain any.
+			b.WriteString(wrap("\x1b]777;notify;" + strings.ReplaceAll(title, ";", ",") + ";" + body + "\a"))
+		case TerminalOSC99:
+			// Each notification needs its own ID, or kitty merges it into
+			// the previous one.
+			id := "nf" + strconv.FormatInt(time.Now().UnixNano(), 36)
+			b.WriteString(wrap("\x1b]99;i=" + id + ":d=0:p=title;" + title + "\x1b\\"))
+			b.WriteString(wrap("\x1b]99;i=" + id + ":d=1:p=body;" + body + "\x1b\\"))
+		case TerminalBell:
+			// Multiplexers handle the bell themselves and notify the
+			// terminal, so it is never wrapped.
+			b.WriteString("\a")
+		}
+	}
+	return b.String()
+}
+
+// sanitizeTerminalText removes control characters, which could end the
+// escape sequence early, and shortens the text to terminalTextBytes.
+func sanitizeTerminalText(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n' || r == '\t':
+			return ' '
+		case r < 0x20 || r == 0x7f || (r >= 0x80 && r < 0xa0):
+			return -1
+		default:
+			return r
+		}
+	}, s)
+	if len(s) > terminalTextBytes {
+		s = truncateUTF8(s, terminalTextBytes) + "..."
+	}
+	return s
+}
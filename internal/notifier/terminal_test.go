@@ -0,0 +1,86 @@
+package notifier
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// envFunc returns a getenv function for the given variables.
+func envFunc(vars map[string]string) func(string) string {
+	return func(key string) string { return vars[key] }
+}
+
+func TestDetectTerminalProtocols(t *testing.T) {
+	testCases := []struct {
+		name     string
+		env      map[string]string
+		expected string
+	}{
+		{name: "kitty", env: map[string]string{"TERM": "xterm-kitty"}, expected: TerminalOSC99},
+		{name: "iTerm2 locally", env: map[string]string{"TERM_PROGRAM": "iTerm.app"}, expected: TerminalOSC9},
+		{name: "iTerm2 over ssh", env: map[string]string{"TERM": "xterm-256color", "LC_TERMINAL": "iTerm2"}, expected: TerminalOSC9},
+		{name: "Windows Terminal", env: map[string]string{"WT_SESSION": "abc"}, expected: TerminalOSC9},
+		{name: "foot", env: map[string]string{"TERM": "foot-extra"}, expected: TerminalOSC777},
+		{name: "urxvt", env: map[string]string{"TERM": "rxvt-unicode-256color"}, expected: TerminalOSC777},
+		{name: "WezTerm", env: map[string]string{"TERM_PROGRAM": "WezTerm"}, expected: TerminalOSC777},
+		{name: "unknown", env: map[string]string{"TERM": "xterm-256color"}, expected: TerminalBell},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, []string{tc.expected}, detectTerminalProtocols(envFunc(tc.env)))
+		})
+	}
+}
+
+func TestTerminalSequences(t *testing.T) {
+	noEnv := envFunc(nil)
+	title, body := "Command Failed: make", "Command `make` failed;\nexit code 2\x1b]"
+
+	assert.Equal(t, "\x1b]9;Command Failed: make: Command `make` failed; exit code 2]\a",
+		terminalSequences(title, body, []string{TerminalOSC9}, noEnv))
+	assert.Equal(t, "\x1b]777;notify;Command Failed: make;Command `make` failed; exit code 2]\a",
+		terminalSequences(title, body, []string{TerminalOSC777}, noEnv))
+	assert.Equal(t, "\x1b]777;notify;a,b;c\a",
+		terminalSequences("a;b", "c", []string{TerminalOSC777}, noEnv))
+	assert.Equal(t, "\a", terminalSequences(title, body, []string{TerminalBell}, noEnv))
+
+	kitty := terminalSequences(title, "done", []string{TerminalOSC99}, noEnv)
+	assert.Regexp(t, regexp.MustCompile(`^\x1b\]99;i=(nf\w+):d=0:p=title;Command Failed: make\x1b\\\x1b\]99;i=(nf\w+):d=1:p=body;done\x1b\\$`), kitty)
+	ids := regexp.MustCompile(`i=(nf\w+)`).FindAllStringSubmatch(kitty, -1)
+	assert.Equal(t, ids[0][1], ids[1][1], "Expected title and body to share a notification ID")
+
+	assert.Equal(t, "\x1bPtmux;\x1b\x1b]9;t: b\a\x1b\\\a",
+		terminalSequences("t", "b", []string{TerminalOSC9, TerminalBell}, envFunc(map[string]string{"TMUX": "/tmp/tmux-1000/default,1,0"})))
+	assert.Equal(t, "\x1bP\x1b]9;t: b\a\x1b\\",
+		terminalSequences("t", "b", []string{TerminalOSC9}, envFunc(map[string]string{"STY": "1234.pts-0.host"})))
+
+	long := terminalSequences(strings.Repeat("é", 200), "b", []string{TerminalOSC9}, noEnv)
+	assert.True(t, strings.HasPrefix(long, "\x1b]9;"+strings.Repeat("é", terminalTextBytes/2)+"...: b"), long)
+}
+
+func TestTerminalNotifier_Send(t *testing.T) {
+	// Keep a multiplexer nf's tests run in from wrapping the sequence.
+	t.Setenv("TMUX", "")
+	t.Setenv("STY", "")
+
+	tty := filepath.Join(t.TempDir(), "tty")
+	require.NoError(t, os.WriteFile(tty, nil, 0o600))
+
+	n := &TerminalNotifier{Protocols: []string{TerminalOSC777}, TTY: tty}
+	require.NoError(t, n.Send(context.Background(), Event{Command: "make", Outcome: OutcomeSucceeded}))
+
+	written, err := os.ReadFile(tty)
+	require.NoError(t, err)
+	assert.Equal(t, "\x1b]777;notify;Command Succeeded: make;Command `make` succeeded in 0.00 seconds.\a", string(written))
+
+	n.TTY = filepath.Join(t.TempDir(), "missing", "tty")
+	assert.ErrorContains(t, n.Send(context.Background(), Event{Command: "make"}), "failed to open terminal")
+}
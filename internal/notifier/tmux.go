@@ -0,0 +1,185 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// TmuxStatusOption is the tmux window option TmuxNotifier sets to the
+// outcome mark, for use in window-status-format as #{@nf_status}.
+const TmuxStatusOption = "@nf_status"
+
+// Marks TmuxNotifier shows for finished commands.
+const (
+	tmuxMarkSucceeded = "✓"
+	tmuxMarkFailed    = "✗"
+)
+
+// TmuxNotifier shows notifications in the terminal multiplexer nf runs in,
+// on the pane or window that launched the command, so that it is easy to
+// tell which of many windows has finished. It works with tmux, and with GNU
+// screen if nf does not run inside tmux.
+type TmuxNotifier struct {
+	// Pane is the tmux pane the command ran in, $TMUX_PANE by default.
+	Pane string
+	// Rename prefixes the window name with a mark for the outcome when the
+	// command finishes. In tmux, only windows with automatic-rename turned
+	// off, e.g. ones named by hand, are renamed.
+	Rename bool
+	// Bell rings the bell in the pane, which flags the window in the
+	// status line.
+	Bell bool
+}
+
+// NewTmuxNotifier creates a new instance of TmuxNotifier for the pane nf
+// runs in.
+func NewTmuxNotifier() *TmuxNotifier {
+	return &TmuxNotifier{Rename: true}
+}
+
+// Send displays the event's title in the multiplexer's status line, marks
+// the window with the outcome once the command has finished, and rings the
+// bell if configured to.
+func (t *TmuxNotifier) Send(ctx context.Context, event Event) error {
+	pane := t.Pane
+	if pane == "" {
+		pane = os.Getenv("TMUX_PANE")
+	}
+	if pane != "" {
+		return t.sendTmux(ctx, pane, event)
+	}
+	if session := os.Getenv("STY"); session != "" {
+		return t.sendScreen(ctx, session, os.Getenv("WINDOW"), event)
+	}
+	return errors.New("failed to send tmux notification: not running inside tmux or screen")
+}
+
+func (t *TmuxNotifier) sendTmux(ctx context.Context, pane string, event Event) error {
+	// display-message expands formats, so a literal # must be doubled.
+	message := strings.ReplaceAll(event.Title(), "#", "##")
+	if _, err := runMultiplexer(ctx, "tmux", "display-message", "-t", pane, message); err != nil {
+		return err
+	}
+
+	if mark := tmuxMark(event); mark != "" {
+		// The option is always set, so that window-status-format can show
+		// the mark without renaming the window.
+		if _, err := runMultiplexer(ctx, "tmux", "set-option", "-w", "-t", pane, TmuxStatusOption, mark); err != nil {
+			return err
+		}
+		if t.Rename {
+			window, err := runMultiplexer(ctx, "tmux", "display-message", "-p", "-t", pane, "#{?automatic-rename,on,off} #{window_name}")
+			if err != nil {
+				return err
+			}
+			// Renaming a window turns automatic-rename off for good, which
+			// would freeze the name on the mark, so windows tmux names
+			// itself only get the @nf_status option.
+			autoRename, name, _ := strings.Cut(window, " ")
+			if autoRename != "on" {
+				if _, err := runMultiplexer(ctx, "tmux", "rename-window", "-t", pane, mark+" "+stripTmuxMark(name)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if t.Bell {
+		tty, err := runMultiplexer(ctx, "tmux", "display-message", "-p", "-t", pane, "#{pane_tty}")
+		if err != nil {
+			return err
+		}
+		return ringBell(tty)
+	}
+	return nil
+}
+
+func (t *TmuxNotifier) sendScreen(ctx context.Context, session, window string, event Event) error {
+	args := []string{"-S", session}
+	if window != "" {
+		args = append(args, "-p", window)
+	}
+	screen := func(cmd ...string) (string, error) {
+		return runMultiplexer(ctx, "screen", append(args, cmd...)...)
+	}
+
+	// echo expands string escapes, so a literal % must be doubled.
+	if _, err := screen("-X", "echo", strings.ReplaceAll(event.Title(), "%", "%%")); err != nil {
+		return err
+	}
+
+	if mark := tmuxMark(event); mark != "" && t.Rename {
+		name, err := screen("-Q", "title")
+		if err != nil {
+			return err
+		}
+		if _, err := screen("-X", "title", mark+" "+stripTmuxMark(name)); err != nil {
+			return err
+		}
+	}
+
+	if t.Bell {
+		// screen has no way to ring the bell in another window, but the
+		// command ran in this one.
+		return ringBell("/dev/tty")
+	}
+	return nil
+}
+
+// tmuxMark returns the mark for a finished command's outcome, or "" while
+// it is still running.
+func tmuxMark(event Event) string {
+	switch event.Outcome {
+	case OutcomeSucceeded:
+		return tmuxMarkSucceeded
+	case OutcomeFailed, OutcomeKilled, OutcomeTimedOut, OutcomeInterrupted:
+		return tmuxMarkFailed
+	default:
+		return ""
+	}
+}
+
+// stripTmuxMark removes the mark of an earlier notification from a window
+// name, so that marks do not pile up.
+func stripTmuxMark(name string) string {
+	for _, mark := range []string{tmuxMarkSucceeded, tmuxMarkFailed} {
+		if rest, ok := strings.CutPrefix(name, mark+" "); ok {
+			return rest
+		}
+	}
+	return name
+}
+
+// runMultiplexer runs a tmux or screen command and returns its output
+// without the trailing newline.
+func runMultiplexer(ctx context.Context, program string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, program, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := lastBytes(strings.TrimSpace(stderr.String()), execStderrBytes); msg != "" {
+			return "", fmt.Errorf("failed to send tmux notification: %s: %w: %s", program, err, msg)
+		}
+		return "", fmt.Errorf("failed to send tmux notification: %s: %w", program, err)
+	}
+	return strings.TrimRight(stdout.String(), "\r\n"), nil
+}
+
+// ringBell writes a BEL character to the terminal at path.
+func ringBell(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("failed to ring bell: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString("\a"); err != nil {
+		return fmt.Errorf("failed to ring bell: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package notifier
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMultiplexer puts a fake tmux and screen on PATH that log their
+// arguments, separated by "|", and answer queries. It returns the log file
+// and the file the fake pane's tty points to.
+func fakeMultiplexer(t *testing.T, windowName string) (log, tty string) {
+	dir := t.TempDir()
+	log = filepath.Join(dir, "log")
+	tty = filepath.Join(dir, "tty")
+	require.NoError(t, os.WriteFile(tty, nil, 0o600))
+
+	script := `#!/bin/sh
+printf '%s|' "$(basename "$0")" "$@" >> "$FAKE_LOG"
+echo >> "$FAKE_LOG"
+case "$*" in
+*'automatic-rename'*) echo "$FAKE_AUTO_RENAME $FAKE_WINDOW_NAME" ;;
+*'-Q title'*) echo "$FAKE_WINDOW_NAME" ;;
+*'#{pane_tty}'*) echo "$FAKE_TTY" ;;
+*'%404'*) echo "can't find pane: %404" >&2; exit 1 ;;
+esac
+`
+	for _, name := range []string{"tmux", "screen"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755))
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("FAKE_LOG", log)
+	t.Setenv("FAKE_TTY", tty)
+	t.Setenv("FAKE_WINDOW_NAME", windowName)
+	t.Setenv("FAKE_AUTO_RENAME", "off")
+	return log, tty
+}
+
+func readLines(t *testing.T, path string) []string {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func TestTmuxNotifier_Send(t *testing.T) {
+	log, tty := fakeMultiplexer(t, "✗ make")
+	t.Setenv("TMUX_PANE", "%3")
+
+	n := NewTmuxNotifier()
+	n.Bell = true
+	require.NoError(t, n.Send(context.Background(), Event{Command: "issue#12", Outcome: OutcomeSucceeded}))
+
+	assert.Equal(t, []string{
+		"tmux|display-message|-t|%3|Command Succeeded: issue##12|",
+		"tmux|set-option|-w|-t|%3|@nf_status|✓|",
+		"tmux|display-message|-p|-t|%3|#{?automatic-rename,on,off} #{window_name}|",
+		"tmux|rename-window|-t|%3|✓ make|",
+		"tmux|display-message|-p|-t|%3|#{pane_tty}|",
+	}, readLines(t, log))
+
+	bell, err := os.ReadFile(tty)
+	require.NoError(t, err)
+	assert.Equal(t, "\a", string(bell))
+}
+
+func TestTmuxNotifier_AutomaticRename(t *testing.T) {
+	log, _ := fakeMultiplexer(t, "make")
+	t.Setenv("FAKE_AUTO_RENAME", "on")
+
+	n := &TmuxNotifier{Pane: "%3", Rename: true}
+	require.NoError(t, n.Send(context.Background(), Event{Command: "make", Outcome: OutcomeFailed, ExitCode: 2}))
+
+	assert.Equal(t, []string{
+		"tmux|display-message|-t|%3|Command Failed: make|",
+		"tmux|set-option|-w|-t|%3|@nf_status|✗|",
+		"tmux|display-message|-p|-t|%3|#{?automatic-rename,on,off} #{window_name}|",
+	}, readLines(t, log), "Expected a window tmux names itself not to be renamed")
+}
+
+func TestTmuxNotifier_Running(t *testing.T) {
+	log, _ := fakeMultiplexer(t, "make")
+
+	n := &TmuxNotifier{Pane: "%7", Rename: true}
+	require.NoError(t, n.Send(context.Background(), Event{Command: "make", Outcome: OutcomeRunning}))
+
+	assert.Equal(t, []string{"tmux|display-message|-t|%7|Command Still Running: make|"}, readLines(t, log),
+		"Expected the window to be left alone while the command runs")
+}
+
+func TestTmuxNotifier_Screen(t *testing.T) {
+	log, _ := fakeMultiplexer(t, "build")
+	t.Setenv("TMUX_PANE", "")
+	t.Setenv("STY", "1234.pts-0.labbox")
+	t.Setenv("WINDOW", "2")
+
+	n := NewTmuxNotifier()
+	require.NoError(t, n.Send(context.Background(), Event{Command: "make", Outcome: OutcomeFailed, ExitCode: 2}))
+
+	assert.Equal(t, []string{
+		"screen|-S|1234.pts-0.labbox|-p|2|-X|echo|Command Failed: make|",
+		"screen|-S|1234.pts-0.labbox|-p|2|-Q|title|",
+		"screen|-S|1234.pts-0.labbox|-p|2|-X|title|✗ build|",
+	}, readLines(t, log))
+}
+
+func TestTmuxNotifier_Errors(t *testing.T) {
+	fakeMultiplexer(t, "make")
+	t.Setenv("TMUX_PANE", "")
+	t.Setenv("STY", "")
+
+	err := NewTmuxNotifier().Send(context.Background(), Event{Command: "make"})
+	assert.EqualError(t, err, "failed to send tmux notification: not running inside tmux or screen")
+
+	err = (&TmuxNotifier{Pane: "%404"}).Send(context.Background(), Event{Command: "make"})
+	assert.EqualError(t, err, "failed to send tmux notification: tmux: exit status 1: can't find pane: %404")
+}
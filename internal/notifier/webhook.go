@@ -0,0 +1,89 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+)
+
+// DefaultWebhookTemplate is the body WebhookNotifier sends if no template
+// is configured.
+const DefaultWebhookTemplate = `{
+  "title": {{json .Title}},
+  "message": {{json .Message}},
+  "command": {{json .CommandLine}},
+  "outcome": {{json .Outcome}},
+  "exit_code": {{if .HasExitCode}}{{.ExitCode}}{{else}}null{{end}},
+  "duration_seconds": {{.Duration.Seconds}},
+  "host": {{json .Host}},
+  "user": {{json .User}},
+  "cwd": {{json .Dir}},
+  "output": {{json (.OutputTail 20 4096)}}
+}
+`
+
+// WebhookNotifier sends notifications to an arbitrary HTTP endpoint, with
+// the request body rendered from a text/template.
+type WebhookNotifier struct {
+	URL    string
+	Method string
+	// Headers are added to every request. Content-Type defaults to
+	// application/json.
+	Headers map[string]string
+	// Body is executed with the Event as its data.
+	Body *template.Template
+}
+
+// webhookFuncs are the functions available to webhook templates, in
+// addition to the text/template builtins.
+var webhookFuncs = template.FuncMap{
+	// json renders a value as JSON, e.g. a string with its quotes.
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	// jsonEscape escapes a string for use inside a JSON string literal.
+	"jsonEscape": func(s string) (string, error) {
+		b, err := json.Marshal(s)
+		if err != nil {
+			return "", err
+		}
+		return string(b[1 : len(b)-1]), nil
+	},
+	"join": func(elems []string, sep string) string {
+		return strings.Join(elems, sep)
+	},
+}
+
+// NewWebhookNotifier creates a new instance of WebhookNotifier. An empty
+// method means POST and an empty body template DefaultWebhookTemplate.
+func NewWebhookNotifier(url, method, bodyTemplate string) (*WebhookNotifier, error) {
+	if method == "" {
+		method = http.MethodPost
+	}
+	if bodyTemplate == "" {
+		bodyTemplate = DefaultWebhookTemplate
+	}
+	body, err := template.New("webhook").Funcs(webhookFuncs).Parse(bodyTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook body template: %w", err)
+	}
+	return &WebhookNotifier{URL: url, Method: strings.ToUpper(method), Body: body}, nil
+}
+
+// Send renders the body template with the event and sends it.
+func (w *WebhookNotifier) Send(ctx context.Context, event Event) error {
+	var body bytes.Buffer
+	if err := w.Body.Execute(&body, event); err != nil {
+		return fmt.Errorf("failed to render webhook body: %w", err)
+	}
+
+	if _, err := sendHTTP(ctx, w.Method, w.URL, w.Headers, &body); err != nil {
+		return fmt.Errorf("failed to send webhook notification: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookNotifier_DefaultTemplate(t *testing.T) {
+	event := Event{
+		Command:  "make",
+		Args:     []string{"test"},
+		Outcome:  OutcomeFailed,
+		ExitCode: 2,
+		Duration: 1500 * time.Millisecond,
+		Host:     "labbox",
+		User:     "jules",
+		Dir:      "/src/nf",
+		Output:   "line \"one\"\nline two",
+	}
+
+	var payloads []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method, "Expected POST request")
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		bodyBytes, err := io.ReadAll(r.Body)
+		require.NoError(t, err, "Failed to read request body")
+
+		var payload map[string]interface{}
+		require.NoError(t, json.Unmarshal(bodyBytes, &payload), "Expected the default body to be valid JSON")
+		payloads = append(payloads, payload)
+	}))
+	defer server.Close()
+
+	n, err := NewWebhookNotifier(server.URL, "", "")
+	require.NoError(t, err)
+	require.NoError(t, n.Send(context.Background(), event), "Send returned an unexpected error")
+	event.Outcome = OutcomeUnknown
+	require.NoError(t, n.Send(context.Background(), event), "Send returned an unexpected error")
+
+	require.Len(t, payloads, 2)
+	assert.Equal(t, map[string]interface{}{
+		"title":            "Command Failed: make",
+		"message":          "Command `make test` failed with exit code 2 after 1.50 seconds.",
+		"command":          "make test",
+		"outcome":          "failed",
+		"exit_code":        float64(2),
+		"duration_seconds": 1.5,
+		"host":             "labbox",
+		"user":             "jules",
+		"cwd":              "/src/nf",
+		"output":           "line \"one\"\nline two",
+	}, payloads[0])
+	assert.Nil(t, payloads[1]["exit_code"], "Expected null without an exit code")
+}
+
+func TestWebhookNotifier_CustomTemplate(t *testing.T) {
+	event := Event{
+		Command:  "deploy",
+		Args:     []string{"--env", "prod"},
+		Outcome:  OutcomeSucceeded,
+		Duration: 2 * time.Minute,
+		Output:   "done \"ok\"",
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PUT", r.Method, "Expected PUT request")
+		assert.Equal(t, "/api/states/sensor.nf", r.URL.Path)
+		assert.Equal(t, "Bearer ha-token", r.Header.Get("Authorization"))
+		assert.Equal(t, "text/plain", r.Header.Get("Content-Type"))
+
+		bodyBytes, err := io.ReadAll(r.Body)
+		require.NoError(t, err, "Failed to read request body")
+		assert.Equal(t, `state=succeeded args=--env,prod secs=120 out="done \"ok\""`, string(bodyBytes))
+	}))
+	defer server.Close()
+
+	n, err := NewWebhookNotifier(server.URL+"/api/states/sensor.nf", "put",
+		`state={{.Outcome}} args={{join .Args ","}} secs={{.Duration.Seconds}} out="{{jsonEscape .Output}}"`)
+	require.NoError(t, err)
+	n.Headers = map[string]string{"authorization": "Bearer ha-token", "Content-Type": "text/plain"}
+	assert.NoError(t, n.Send(context.Background(), event), "Send returned an unexpected error")
+}
+
+func TestWebhookNotifier_Errors(t *testing.T) {
+	_, err := NewWebhookNotifier("http://localhost", "", "{{.Title")
+	assert.ErrorContains(t, err, "invalid webhook body template")
+
+	n, err := NewWebhookNotifier("http://localhost", "", "{{.NoSuchField}}")
+	require.NoError(t, err)
+	err = n.Send(context.Background(), Event{Command: "make"})
+	assert.ErrorContains(t, err, "failed to render webhook body")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	n, err = NewWebhookNotifier(server.URL, "", "")
+	require.NoError(t, err)
+	err = n.Send(context.Background(), Event{Command: "make"})
+	assert.EqualError(t, err, "failed to send webhook notification: received status code 401")
+}